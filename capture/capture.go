@@ -0,0 +1,47 @@
+package capture
+
+import (
+	"image"
+	"image/png"
+	"os"
+)
+
+// SavePNG writes the frame to a PNG file
+func SavePNG(path string, frame image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, frame); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Scale the frame up by an integer factor, nearest neighbour, as the GIF
+// recorder does
+func Scale(frame *image.RGBA, scale int) *image.RGBA {
+	if scale <= 1 {
+		return frame
+	}
+
+	size := frame.Rect.Size()
+	out := image.NewRGBA(image.Rect(0, 0, size.X*scale, size.Y*scale))
+	src := frame.Pix[frame.PixOffset(frame.Rect.Min.X, frame.Rect.Min.Y):]
+	scalePix(out.Pix, out.Stride, src, frame.Stride, size, 4, scale)
+	return out
+}
+
+// copy the rows of pixels of pixelSize bytes, each pixel repeated scale times
+// across and down
+func scalePix(dst []byte, dstStride int, src []byte, srcStride int, size image.Point, pixelSize, scale int) {
+	for y := 0; y < size.Y*scale; y++ {
+		in := src[(y/scale)*srcStride:]
+		out := dst[y*dstStride:]
+		for x := 0; x < size.X*scale; x++ {
+			copy(out[x*pixelSize:(x+1)*pixelSize], in[(x/scale)*pixelSize:])
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package capture
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScale(t *testing.T) {
+	// a sub-image, so the frame does not start at the origin
+	full := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	full.SetRGBA(1, 1, color.RGBA{0xff, 0x20, 0x20, 0xff})
+	full.SetRGBA(3, 2, color.RGBA{0x20, 0xff, 0x20, 0xff})
+	frame := full.SubImage(image.Rect(1, 1, 4, 3)).(*image.RGBA)
+
+	tests := []struct {
+		scale int
+		size  image.Point
+	}{
+		{0, image.Pt(3, 2)},
+		{1, image.Pt(3, 2)},
+		{2, image.Pt(6, 4)},
+		{3, image.Pt(9, 6)},
+	}
+
+	for _, test := range tests {
+		out := Scale(frame, test.scale)
+		if got := out.Rect.Size(); got != test.size {
+			t.Errorf("scale %d: size %v, want %v", test.scale, got, test.size)
+			continue
+		}
+
+		scale := test.size.X / 3
+		for y := 0; y < test.size.Y; y++ {
+			for x := 0; x < test.size.X; x++ {
+				min := out.Rect.Min
+				got := out.RGBAAt(min.X+x, min.Y+y)
+				want := frame.RGBAAt(frame.Rect.Min.X+x/scale, frame.Rect.Min.Y+y/scale)
+				if got != want {
+					t.Fatalf("scale %d: pixel %d,%d is %v, want %v", test.scale, x, y, got, want)
+				}
+			}
+		}
+	}
+}
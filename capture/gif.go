@@ -0,0 +1,261 @@
+package capture
+
+import (
+	"bufio"
+	"compress/lzw"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"io"
+)
+
+// GIF players stretch shorter frame delays, in 1/100 s
+const minGIFDelay = 2
+
+// GIFRecorder streams frames into an animated GIF at the emulated frame
+// rate, only the frame waiting for its delay is kept. GIF delays are in
+// 1/100 s, so frames are dropped as needed to keep the timing and no delay
+// goes under what players honour
+type GIFRecorder struct {
+	w         *bufio.Writer
+	frameRate float64
+	scale     int
+
+	size    image.Point // of the frames once scaled
+	pending *image.Paletted
+	frames  int
+
+	// time of the frames seen and of the frame pending, in 1/100 s
+	elapsed float64
+	kept    float64
+}
+
+// NewGIFRecorder for frames emitted at frameRate per second, scaled up by an
+// integer factor. The frame size is taken from the first frame
+func NewGIFRecorder(w io.Writer, frameRate float64, scale int) *GIFRecorder {
+	if scale < 1 {
+		scale = 1
+	}
+	return &GIFRecorder{w: bufio.NewWriter(w), frameRate: frameRate, scale: scale}
+}
+
+// Frames recorded so far, the one pending included
+func (rec *GIFRecorder) Frames() int {
+	return rec.frames
+}
+
+// AddFrame to the animation, every frame must have the size of the first
+func (rec *GIFRecorder) AddFrame(frame *image.RGBA) error {
+	size := frame.Rect.Size().Mul(rec.scale)
+	if rec.frames == 0 {
+		rec.size = size
+		if err := rec.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if size != rec.size {
+		return fmt.Errorf("frame size changed from %v to %v", rec.size.Div(rec.scale), frame.Rect.Size())
+	}
+
+	now := rec.elapsed
+	rec.elapsed += 100 / rec.frameRate
+
+	// the pending frame is written once the delay to this one is known
+	if rec.pending != nil {
+		delay := int(now+0.5) - int(rec.kept+0.5)
+		if delay < minGIFDelay {
+			return nil
+		}
+		if err := rec.writeFrame(rec.pending, delay); err != nil {
+			return err
+		}
+	}
+	rec.kept = now
+
+	rec.pending = scaled(paletted(frame), rec.scale)
+	rec.frames++
+	return nil
+}
+
+// Close writes the last frame and ends the animation, the underlying writer
+// is left open
+func (rec *GIFRecorder) Close() error {
+	if rec.frames == 0 {
+		return fmt.Errorf("no frame recorded")
+	}
+	if err := rec.writeFrame(rec.pending, minGIFDelay); err != nil {
+		return err
+	}
+	rec.pending = nil
+
+	if err := rec.w.WriteByte(0x3b); err != nil { // trailer
+		return err
+	}
+	return rec.w.Flush()
+}
+
+// header, logical screen without a global colour table, and looping forever
+func (rec *GIFRecorder) writeHeader() error {
+	header := []interface{}{
+		[6]byte{'G', 'I', 'F', '8', '9', 'a'},
+		uint16(rec.size.X), uint16(rec.size.Y),
+		[3]byte{0, 0, 0}, // flags, background, aspect ratio
+		[3]byte{0x21, 0xff, 11},
+		[11]byte{'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0'},
+		[2]byte{3, 1}, uint16(0), uint8(0), // loop count, 0 for forever
+	}
+	for _, field := range header {
+		if err := binary.Write(rec.w, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frame with its own colour table, shown for delay 1/100 s
+func (rec *GIFRecorder) writeFrame(img *image.Paletted, delay int) error {
+	// the table holds a power of two colours, 4 at least for LZW
+	bits := 2
+	for 1<<uint(bits) < len(img.Palette) {
+		bits++
+	}
+
+	header := []interface{}{
+		[4]byte{0x21, 0xf9, 4, 0}, uint16(delay), [2]byte{0, 0}, // graphic control
+		uint8(0x2c), uint16(0), uint16(0), uint16(rec.size.X), uint16(rec.size.Y),
+		uint8(0x80 | (bits - 1)), // local colour table
+	}
+	for _, field := range header {
+		if err := binary.Write(rec.w, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	table := make([]byte, 3<<uint(bits))
+	for i, c := range img.Palette {
+		r, g, b, _ := c.RGBA()
+		table[3*i], table[3*i+1], table[3*i+2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+	}
+	if _, err := rec.w.Write(table); err != nil {
+		return err
+	}
+
+	if err := rec.w.WriteByte(uint8(bits)); err != nil {
+		return err
+	}
+	blocks := &gifBlocks{w: rec.w}
+	lzww := lzw.NewWriter(blocks, lzw.LSB, bits)
+	for y := 0; y < rec.size.Y; y++ {
+		if _, err := lzww.Write(img.Pix[y*img.Stride : y*img.Stride+rec.size.X]); err != nil {
+			return err
+		}
+	}
+	if err := lzww.Close(); err != nil {
+		return err
+	}
+	return blocks.close()
+}
+
+// splits the image data into the sub-blocks of up to 255 bytes of the
+// format, ended by an empty one
+type gifBlocks struct {
+	w   *bufio.Writer
+	buf [255]byte
+	n   int
+}
+
+func (b *gifBlocks) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(b.buf[b.n:], p)
+		b.n += n
+		p = p[n:]
+		written += n
+		if b.n == len(b.buf) {
+			if err := b.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (b *gifBlocks) flush() error {
+	if b.n == 0 {
+		return nil
+	}
+	if err := b.w.WriteByte(uint8(b.n)); err != nil {
+		return err
+	}
+	_, err := b.w.Write(b.buf[:b.n])
+	b.n = 0
+	return err
+}
+
+func (b *gifBlocks) close() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	return b.w.WriteByte(0)
+}
+
+// the image scaled up by an integer factor, nearest neighbour
+func scaled(img *image.Paletted, scale int) *image.Paletted {
+	if scale == 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	out := image.NewPaletted(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale), img.Palette)
+	scalePix(out.Pix, out.Stride, img.Pix, img.Stride, bounds.Size(), 1, scale)
+	return out
+}
+
+// convert the frame to a paletted image, exact when it has at most 256
+// colours, which is the case without filters, dithered to a fixed palette
+// otherwise
+func paletted(frame *image.RGBA) *image.Paletted {
+	bounds := frame.Bounds()
+	rect := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+
+	colors, pal := framePalette(frame)
+	if pal == nil {
+		img := image.NewPaletted(rect, palette.Plan9)
+		draw.FloydSteinberg.Draw(img, rect, frame, bounds.Min)
+		return img
+	}
+
+	img := image.NewPaletted(rect, pal)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetColorIndex(x-bounds.Min.X, y-bounds.Min.Y, colors[frame.RGBAAt(x, y)])
+		}
+	}
+	return img
+}
+
+// the colours of the frame and their index, nil if there are more than 256
+func framePalette(frame *image.RGBA) (map[color.RGBA]uint8, color.Palette) {
+	bounds := frame.Bounds()
+
+	colors := make(map[color.RGBA]uint8)
+	var pal color.Palette
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := frame.RGBAAt(x, y)
+			if _, ok := colors[c]; ok {
+				continue
+			}
+			if len(pal) == 256 {
+				return nil, nil
+			}
+			colors[c] = uint8(len(pal))
+			pal = append(pal, c)
+		}
+	}
+
+	return colors, pal
+}
@@ -0,0 +1,111 @@
+package capture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"reflect"
+	"testing"
+)
+
+// frame of the size with a pixel lit at x, y
+func testFrame(size image.Point, x, y int) *image.RGBA {
+	frame := image.NewRGBA(image.Rectangle{Max: size})
+	for i := 3; i < len(frame.Pix); i += 4 {
+		frame.Pix[i] = 0xff
+	}
+	frame.SetRGBA(x, y, color.RGBA{0xff, 0x20, 0x20, 0xff})
+	return frame
+}
+
+func TestGIFRecorder(t *testing.T) {
+	tests := []struct {
+		name      string
+		frameRate float64
+		scale     int
+		frames    int
+		delays    []int
+	}{
+		{"every frame", 50, 1, 4, []int{2, 2, 2, 2}},
+		{"half the frames", 100, 1, 5, []int{2, 2, 2}},
+		{"uneven", 60, 1, 7, []int{2, 3, 2, 3, 2}},
+		{"scaled", 50, 3, 2, []int{2, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rec := NewGIFRecorder(&buf, test.frameRate, test.scale)
+			size := image.Pt(7, 5)
+			for i := 0; i < test.frames; i++ {
+				if err := rec.AddFrame(testFrame(size, i%size.X, 2)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := rec.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			anim, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+			if !reflect.DeepEqual(anim.Delay, test.delays) {
+				t.Errorf("delays %v, want %v", anim.Delay, test.delays)
+			}
+			if rec.Frames() != len(test.delays) {
+				t.Errorf("%d frames counted, want %d", rec.Frames(), len(test.delays))
+			}
+
+			want := size.Mul(test.scale)
+			if got := image.Pt(anim.Config.Width, anim.Config.Height); got != want {
+				t.Errorf("size %v, want %v", got, want)
+			}
+			first := anim.Image[0]
+			for y := 0; y < want.Y; y++ {
+				for x := 0; x < want.X; x++ {
+					r, _, _, _ := first.At(x, y).RGBA()
+					lit := x/test.scale == 0 && y/test.scale == 2
+					if (r>>8 == 0xff) != lit {
+						t.Fatalf("pixel %d,%d: red %02x, lit %v", x, y, r>>8, lit)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGIFRecorderDithered(t *testing.T) {
+	// more colours than a table holds
+	frame := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for i := range frame.Pix {
+		frame.Pix[i] = uint8(i * 7)
+	}
+
+	var buf bytes.Buffer
+	rec := NewGIFRecorder(&buf, 50, 1)
+	if err := rec.AddFrame(frame); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gif.DecodeAll(&buf); err != nil {
+		t.Errorf("decoding: %v", err)
+	}
+}
+
+func TestGIFRecorderErrors(t *testing.T) {
+	rec := NewGIFRecorder(&bytes.Buffer{}, 50, 1)
+	if err := rec.Close(); err == nil {
+		t.Errorf("closed without frames")
+	}
+
+	if err := rec.AddFrame(testFrame(image.Pt(4, 4), 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.AddFrame(testFrame(image.Pt(8, 4), 0, 0)); err == nil {
+		t.Errorf("frame of another size added")
+	}
+}
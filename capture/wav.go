@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// WAVWriter streams 16-bit mono PCM into a WAV file, the sizes in the header
+// are written on Close
+type WAVWriter struct {
+	ws      io.WriteSeeker
+	w       *bufio.Writer
+	samples uint32
+}
+
+// NewWAVWriter at the given sample rate
+func NewWAVWriter(ws io.WriteSeeker, sampleRate int) (*WAVWriter, error) {
+	wav := &WAVWriter{ws: ws, w: bufio.NewWriter(ws)}
+
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'}, uint32(0), [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16),
+		uint16(1), // PCM
+		uint16(1), // mono
+		uint32(sampleRate),
+		uint32(sampleRate * 2), // byte rate
+		uint16(2),              // block align
+		uint16(16),             // bits per sample
+		[4]byte{'d', 'a', 't', 'a'}, uint32(0),
+	}
+	for _, field := range header {
+		if err := binary.Write(wav.w, binary.LittleEndian, field); err != nil {
+			return nil, err
+		}
+	}
+
+	return wav, nil
+}
+
+// WriteSamples appends samples
+func (wav *WAVWriter) WriteSamples(samples []int16) error {
+	wav.samples += uint32(len(samples))
+	return binary.Write(wav.w, binary.LittleEndian, samples)
+}
+
+// Close fills in the sizes of the header, the underlying writer is left open
+func (wav *WAVWriter) Close() error {
+	if err := wav.w.Flush(); err != nil {
+		return err
+	}
+
+	dataSize := wav.samples * 2
+	patches := []struct {
+		offset int64
+		value  uint32
+	}{
+		{4, 36 + dataSize},
+		{40, dataSize},
+	}
+	for _, patch := range patches {
+		if _, err := wav.ws.Seek(patch.offset, io.SeekStart); err != nil {
+			return err
+		}
+		if err := binary.Write(wav.ws, binary.LittleEndian, patch.value); err != nil {
+			return err
+		}
+	}
+
+	_, err := wav.ws.Seek(0, io.SeekEnd)
+	return err
+}
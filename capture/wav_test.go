@@ -0,0 +1,73 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAVWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sound.wav")
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wav, err := NewWAVWriter(file, 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, samples := range [][]int16{{0, 1, -1}, {}, {32767, -32768}} {
+		if err := wav.WriteSamples(samples); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wav.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 44+5*2 {
+		t.Fatalf("%d bytes, want %d", len(data), 44+5*2)
+	}
+
+	le := binary.LittleEndian
+	fields := []struct {
+		name   string
+		offset int
+		got    uint32
+		want   uint32
+	}{
+		{"RIFF size", 4, le.Uint32(data[4:]), uint32(len(data) - 8)},
+		{"fmt size", 16, le.Uint32(data[16:]), 16},
+		{"sample rate", 24, le.Uint32(data[24:]), 44100},
+		{"byte rate", 28, le.Uint32(data[28:]), 88200},
+		{"data size", 40, le.Uint32(data[40:]), 10},
+	}
+	for _, field := range fields {
+		if field.got != field.want {
+			t.Errorf("%s at %d: %d, want %d", field.name, field.offset, field.got, field.want)
+		}
+	}
+	for offset, tag := range map[int]string{0: "RIFF", 8: "WAVE", 12: "fmt ", 36: "data"} {
+		if got := string(data[offset : offset+4]); got != tag {
+			t.Errorf("%q at %d, want %q", got, offset, tag)
+		}
+	}
+	if last := int16(le.Uint16(data[len(data)-2:])); last != -32768 {
+		t.Errorf("last sample %d, want -32768", last)
+	}
+}
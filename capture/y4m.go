@@ -0,0 +1,68 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Y4MWriter streams frames as an uncompressed YUV4MPEG2 video, 4:4:4 so the
+// overlay colours are kept exactly. The samples are full range, as converted
+// by color.RGBToYCbCr, and the header says so
+type Y4MWriter struct {
+	w         *bufio.Writer
+	rateNum   int
+	rateDen   int
+	size      image.Point
+	plane     []byte
+	hasHeader bool
+}
+
+// NewY4MWriter at a frame rate of rateNum/rateDen frames per second, the
+// frame size is taken from the first frame
+func NewY4MWriter(w io.Writer, rateNum, rateDen int) *Y4MWriter {
+	return &Y4MWriter{w: bufio.NewWriter(w), rateNum: rateNum, rateDen: rateDen}
+}
+
+// WriteFrame appends a frame, every frame must have the size of the first
+func (y4m *Y4MWriter) WriteFrame(frame *image.RGBA) error {
+	size := frame.Rect.Size()
+
+	if !y4m.hasHeader {
+		y4m.size = size
+		y4m.plane = make([]byte, size.X*size.Y*3)
+		if _, err := fmt.Fprintf(y4m.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444 XCOLORRANGE=FULL\n",
+			size.X, size.Y, y4m.rateNum, y4m.rateDen); err != nil {
+			return err
+		}
+		y4m.hasHeader = true
+	}
+	if size != y4m.size {
+		return fmt.Errorf("frame size changed from %v to %v", y4m.size, size)
+	}
+
+	// planar Y, Cb and Cr
+	n := size.X * size.Y
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			i := (y-frame.Rect.Min.Y)*frame.Stride + (x-frame.Rect.Min.X)*4
+			yy, cb, cr := color.RGBToYCbCr(frame.Pix[i], frame.Pix[i+1], frame.Pix[i+2])
+
+			p := y*size.X + x
+			y4m.plane[p], y4m.plane[n+p], y4m.plane[2*n+p] = yy, cb, cr
+		}
+	}
+
+	if _, err := io.WriteString(y4m.w, "FRAME\n"); err != nil {
+		return err
+	}
+	_, err := y4m.w.Write(y4m.plane)
+	return err
+}
+
+// Flush buffered frames
+func (y4m *Y4MWriter) Flush() error {
+	return y4m.w.Flush()
+}
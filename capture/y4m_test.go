@@ -0,0 +1,73 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestY4MWriter(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	frame.SetRGBA(0, 0, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	frame.SetRGBA(1, 0, color.RGBA{0xff, 0x00, 0x00, 0xff})
+	frame.SetRGBA(2, 1, color.RGBA{0x00, 0x00, 0x00, 0xff})
+
+	var buf bytes.Buffer
+	y4m := NewY4MWriter(&buf, 1996800, 33536)
+	for i := 0; i < 2; i++ {
+		if err := y4m.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := y4m.WriteFrame(image.NewRGBA(image.Rect(0, 0, 2, 2))); err == nil {
+		t.Errorf("frame of another size written")
+	}
+	if err := y4m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&buf)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"YUV4MPEG2", "W3", "H2", "F1996800:33536", "Ip", "A1:1", "C444", "XCOLORRANGE=FULL"}
+	if fields := strings.Fields(header); !reflect.DeepEqual(fields, want) {
+		t.Errorf("header %v, want %v", fields, want)
+	}
+
+	// full range samples, planar Y, Cb, Cr
+	pixels := []struct {
+		x, y       int
+		yy, cb, cr uint8
+	}{
+		{0, 0, 255, 128, 128},
+		{1, 0, 76, 85, 255},
+		{2, 1, 0, 128, 128},
+	}
+	for i := 0; i < 2; i++ {
+		marker, err := r.ReadString('\n')
+		if err != nil || marker != "FRAME\n" {
+			t.Fatalf("frame %d: marker %q, %v", i, marker, err)
+		}
+		planes := make([]byte, 3*3*2)
+		if _, err := io.ReadFull(r, planes); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		for _, p := range pixels {
+			at := p.y*3 + p.x
+			got := [3]uint8{planes[at], planes[6+at], planes[12+at]}
+			if got != [3]uint8{p.yy, p.cb, p.cr} {
+				t.Errorf("frame %d, pixel %d,%d: YCbCr %v, want %v", i, p.x, p.y, got, [3]uint8{p.yy, p.cb, p.cr})
+			}
+		}
+	}
+	if rest, _ := r.ReadString('\n'); rest != "" {
+		t.Errorf("%d bytes after the frames", len(rest))
+	}
+}
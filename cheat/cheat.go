@@ -0,0 +1,276 @@
+// Package cheat pokes values into the machine memory every frame, and
+// searches the RAM for the variables worth poking
+package cheat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Memory the cheats read and write, a Machine satisfies it
+type Memory interface {
+	Peek(addr uint16) uint8
+	Poke(addr uint16, value uint8)
+}
+
+// Cheat writes a value to an address while its condition holds
+type Cheat struct {
+	Address     uint16
+	Value       uint8
+	Condition   Condition
+	Description string
+	Enabled     bool
+}
+
+// When of a condition
+type When int
+
+// Conditions, the comparisons are on the byte at the address of the
+// condition
+const (
+	Always     When = iota // every frame, freezing the address
+	Once                   // the first frame after it is enabled
+	IfEqual                // every frame while equal
+	IfNotEqual             // every frame while different
+	IfLess                 // every frame while lower
+	IfGreater              // every frame while higher
+)
+
+var comparisons = []struct {
+	op   string
+	when When
+}{
+	// two characters first, so != is not read as =
+	{"!=", IfNotEqual}, {"=", IfEqual}, {"<", IfLess}, {">", IfGreater},
+}
+
+// Condition under which a cheat writes its value
+type Condition struct {
+	When    When
+	Address uint16
+	Value   uint8
+}
+
+// Holds on the memory
+func (c Condition) Holds(mem Memory) bool {
+	value := mem.Peek(c.Address)
+	switch c.When {
+	case IfEqual:
+		return value == c.Value
+	case IfNotEqual:
+		return value != c.Value
+	case IfLess:
+		return value < c.Value
+	case IfGreater:
+		return value > c.Value
+	}
+	return true
+}
+
+func (c Condition) String() string {
+	switch c.When {
+	case Always:
+		return "always"
+	case Once:
+		return "once"
+	}
+	for _, cmp := range comparisons {
+		if cmp.when == c.When {
+			return fmt.Sprintf("%04x%s%02x", c.Address, cmp.op, c.Value)
+		}
+	}
+	return "?"
+}
+
+// ParseCondition reads always, once or a comparison like 20ef=01, with
+// hexadecimal numbers
+func ParseCondition(s string) (Condition, error) {
+	switch s {
+	case "always":
+		return Condition{When: Always}, nil
+	case "once":
+		return Condition{When: Once}, nil
+	}
+
+	for _, cmp := range comparisons {
+		i := strings.Index(s, cmp.op)
+		if i < 0 {
+			continue
+		}
+		addr, err := ParseAddress(s[:i])
+		if err != nil {
+			return Condition{}, err
+		}
+		value, err := ParseValue(s[i+len(cmp.op):])
+		if err != nil {
+			return Condition{}, err
+		}
+		return Condition{When: cmp.when, Address: addr, Value: value}, nil
+	}
+
+	return Condition{}, fmt.Errorf("unknown condition %q", s)
+}
+
+// ParseAddress reads a hexadecimal address
+func ParseAddress(s string) (uint16, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("bad address %q", s)
+	}
+	return uint16(n), nil
+}
+
+// ParseValue reads a hexadecimal byte
+func ParseValue(s string) (uint8, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return uint8(n), nil
+}
+
+// String is the cheat as a line of a cheat file
+func (c Cheat) String() string {
+	line := fmt.Sprintf("%04x %02x %s %s", c.Address, c.Value, c.Condition, c.Description)
+	if !c.Enabled {
+		line = "-" + line
+	}
+	return strings.TrimSpace(line)
+}
+
+// Apply the enabled cheats, once cheats disable themselves after writing
+func Apply(mem Memory, cheats []Cheat) {
+	for i := range cheats {
+		c := &cheats[i]
+		if !c.Enabled || !c.Condition.Holds(mem) {
+			continue
+		}
+
+		mem.Poke(c.Address, c.Value)
+		if c.Condition.When == Once {
+			c.Enabled = false
+		}
+	}
+}
+
+// Parse cheats, one per line as
+//
+//	# address value condition description
+//	21ff 03 always Infinite lives
+//	2015 ff 20ef=01 Invincibility
+//	-20eb 99 once 99 credits
+//
+// Numbers are hexadecimal, a leading - disables the cheat
+func Parse(r io.Reader) ([]Cheat, error) {
+	var cheats []Cheat
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		c, err := ParseCheat(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cheats = append(cheats, c)
+	}
+
+	return cheats, scanner.Err()
+}
+
+// ParseCheat reads a cheat as a line of a cheat file
+func ParseCheat(s string) (Cheat, error) {
+	enabled := !strings.HasPrefix(s, "-")
+	fields := strings.Fields(strings.TrimPrefix(s, "-"))
+	if len(fields) < 3 {
+		return Cheat{}, fmt.Errorf("expected address value condition description")
+	}
+
+	c := Cheat{Description: strings.Join(fields[3:], " "), Enabled: enabled}
+	var err error
+	if c.Address, err = ParseAddress(fields[0]); err != nil {
+		return Cheat{}, err
+	}
+	if c.Value, err = ParseValue(fields[1]); err != nil {
+		return Cheat{}, err
+	}
+	if c.Condition, err = ParseCondition(fields[2]); err != nil {
+		return Cheat{}, err
+	}
+	return c, nil
+}
+
+// Load the cheats of a file
+func Load(path string) ([]Cheat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cheats, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cheats, nil
+}
+
+// Write the cheats in the format read by Parse
+func Write(w io.Writer, cheats []Cheat) error {
+	if _, err := fmt.Fprintln(w, "# address value condition description"); err != nil {
+		return err
+	}
+	for _, c := range cheats {
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save the cheats to a file, creating its directory
+func Save(path string, cheats []Cheat) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, cheats); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Path of the cheat file of a ROM set in dir, by the hash of the ROM. A
+// file saved there replaces the built-in cheats
+func Path(dir, romHash string) string {
+	return filepath.Join(dir, romHash+".cht")
+}
+
+// built-in cheats by profile, disabled
+var builtins = map[string][]Cheat{
+	"invaders": {
+		{Address: 0x21ff, Value: 0x03, Condition: Condition{When: Always}, Description: "Infinite lives (player 1)"},
+		{Address: 0x22ff, Value: 0x03, Condition: Condition{When: Always}, Description: "Infinite lives (player 2)"},
+		{Address: 0x2015, Value: 0xff, Condition: Condition{When: IfEqual, Address: 0x20ef, Value: 0x01},
+			Description: "Invincibility"},
+		{Address: 0x20eb, Value: 0x99, Condition: Condition{When: Once}, Description: "99 credits"},
+	},
+}
+
+// Builtins of the game with the profile name, disabled
+func Builtins(profile string) []Cheat {
+	return append([]Cheat(nil), builtins[profile]...)
+}
@@ -0,0 +1,108 @@
+package cheat
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		s    string
+		cond Condition
+		err  string
+	}{
+		{"always", Condition{When: Always}, ""},
+		{"once", Condition{When: Once}, ""},
+		{"20ef=01", Condition{IfEqual, 0x20ef, 0x01}, ""},
+		{"20EF!=ff", Condition{IfNotEqual, 0x20ef, 0xff}, ""},
+		{"0x2000<0x10", Condition{IfLess, 0x2000, 0x10}, ""},
+		{"3fff>9", Condition{IfGreater, 0x3fff, 0x09}, ""},
+		{"sometimes", Condition{}, `unknown condition "sometimes"`},
+		{"=01", Condition{}, `bad address ""`},
+		{"10000=01", Condition{}, `bad address "10000"`},
+		{"20ef=100", Condition{}, `bad value "100"`},
+		{"20ef=", Condition{}, `bad value ""`},
+		{"20ef<=01", Condition{}, `bad address "20ef<"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			cond, err := ParseCondition(test.s)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if cond != test.cond {
+				t.Errorf("got %+v, want %+v", cond, test.cond)
+			}
+
+			// and it reads back from its string
+			again, err := ParseCondition(cond.String())
+			if err != nil || again != cond {
+				t.Errorf("%q read back as %+v, %v", cond.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		cheats []Cheat
+		err    string
+	}{
+		{"empty", "", nil, ""},
+		{"comments and blank lines", "# address value condition description\n\n   \n", nil, ""},
+		{
+			"cheats",
+			"21ff 03 always Infinite lives\n  2015 ff 20ef=01   Invincibility \n-20eb 99 once 99 credits\n",
+			[]Cheat{
+				{0x21ff, 0x03, Condition{When: Always}, "Infinite lives", true},
+				{0x2015, 0xff, Condition{IfEqual, 0x20ef, 0x01}, "Invincibility", true},
+				{0x20eb, 0x99, Condition{When: Once}, "99 credits", false},
+			},
+			"",
+		},
+		{"no description", "21ff 03 always", []Cheat{{0x21ff, 0x03, Condition{When: Always}, "", true}}, ""},
+		{"too few fields", "# header\n21ff 03\n", nil, "line 2: expected address value condition description"},
+		{"bad address", "21ff 03 always\nzz 03 always\n", nil, `line 2: bad address "zz"`},
+		{"bad value", "21ff 300 always", nil, `line 1: bad value "300"`},
+		{"bad condition", "21ff 03 never", nil, `line 1: unknown condition "never"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cheats, err := Parse(strings.NewReader(test.text))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(cheats, test.cheats) {
+				t.Errorf("got %+v, want %+v", cheats, test.cheats)
+			}
+
+			// Write gives back the same cheats
+			var buf bytes.Buffer
+			if err := Write(&buf, cheats); err != nil {
+				t.Fatal(err)
+			}
+			again, err := Parse(&buf)
+			if err != nil || !reflect.DeepEqual(again, cheats) {
+				t.Errorf("written cheats read back as %+v, %v", again, err)
+			}
+		})
+	}
+}
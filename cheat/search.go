@@ -0,0 +1,102 @@
+package cheat
+
+import "fmt"
+
+// Comparison narrowing a search
+type Comparison int
+
+// Comparisons, against the value of each address when the search last
+// looked at it, or the value given for Exact
+const (
+	Exact Comparison = iota
+	Changed
+	Unchanged
+	Greater
+	Less
+)
+
+var comparisonNames = []string{"exact", "changed", "unchanged", "greater", "less"}
+
+func (cmp Comparison) String() string {
+	if cmp < 0 || int(cmp) >= len(comparisonNames) {
+		return fmt.Sprintf("Comparison(%d)", int(cmp))
+	}
+	return comparisonNames[cmp]
+}
+
+// Search narrows down the addresses holding a variable, comparing memory
+// across frames
+type Search struct {
+	candidates []uint16
+	values     map[uint16]uint8 // when last looked at
+	previous   map[uint16]uint8 // before that
+}
+
+// Candidate address of a search
+type Candidate struct {
+	Address  uint16
+	Value    uint8
+	Previous uint8 // before the last comparison
+}
+
+// NewSearch over the addresses from start to end, both included
+func NewSearch(mem Memory, start, end uint16) *Search {
+	s := &Search{values: make(map[uint16]uint8), previous: make(map[uint16]uint8)}
+	for addr := int(start); addr <= int(end); addr++ {
+		value := mem.Peek(uint16(addr))
+		s.candidates = append(s.candidates, uint16(addr))
+		s.values[uint16(addr)], s.previous[uint16(addr)] = value, value
+	}
+	return s
+}
+
+// Filter keeps the candidates passing the comparison, value is only used by
+// Exact. The values are then taken again for the next comparison
+func (s *Search) Filter(mem Memory, cmp Comparison, value uint8) {
+	kept := s.candidates[:0]
+	values := make(map[uint16]uint8, len(s.candidates))
+	previous := make(map[uint16]uint8, len(s.candidates))
+
+	for _, addr := range s.candidates {
+		now, before := mem.Peek(addr), s.values[addr]
+
+		var pass bool
+		switch cmp {
+		case Exact:
+			pass = now == value
+		case Changed:
+			pass = now != before
+		case Unchanged:
+			pass = now == before
+		case Greater:
+			pass = now > before
+		case Less:
+			pass = now < before
+		}
+
+		if pass {
+			kept = append(kept, addr)
+			values[addr], previous[addr] = now, before
+		}
+	}
+
+	s.candidates, s.values, s.previous = kept, values, previous
+}
+
+// Count of the candidates left
+func (s *Search) Count() int {
+	return len(s.candidates)
+}
+
+// Candidates left, at most limit of them with their value now and before
+// the last comparison
+func (s *Search) Candidates(mem Memory, limit int) []Candidate {
+	var list []Candidate
+	for _, addr := range s.candidates {
+		if len(list) == limit {
+			break
+		}
+		list = append(list, Candidate{Address: addr, Value: mem.Peek(addr), Previous: s.previous[addr]})
+	}
+	return list
+}
@@ -0,0 +1,68 @@
+// Command invaders-gym serves the Space Invaders environment over a socket,
+// for trainers written in other languages
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/protoshark/invaders8080/env"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:5555", "address to listen on, unix:path for a unix socket")
+	frameSkip := flag.Int("frameskip", env.DefaultOptions.FrameSkip, "frames emulated per step")
+	downsample := flag.Int("downsample", env.DefaultOptions.Downsample, "observation shrink factor")
+	sticky := flag.Float64("sticky", env.DefaultOptions.Sticky, "probability the previous action is kept for a frame")
+	seed := flag.Int64("seed", 0, "seed of the sticky actions")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: invaders-gym [flags] <rom directory | rom.zip | rom files...>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := env.Options{FrameSkip: *frameSkip, Downsample: *downsample, Sticky: *sticky, Seed: *seed}
+	newEnv := func() (*env.Env, error) {
+		machine, err := invaders.NewMachine(invaders.DefaultConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := machine.LoadROMFiles(args...); err != nil {
+			return nil, err
+		}
+		return env.New(machine, options)
+	}
+
+	// fail early on bad ROMs or options
+	if _, err := newEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	network, address := "tcp", *listen
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Listening on %s\n", *listen)
+
+	if err := env.Serve(listener, newEnv); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -57,8 +57,20 @@ const (
 	// CPU ROM end region
 	RomOffset  = 0x2000
 	VRAMOffset = 0x2400
+	RAMEnd     = 0x3fff
 )
 
+// Region of the address space, both ends included
+type Region struct {
+	Start uint16
+	End   uint16
+}
+
+// Contains reports whether the address falls in the region
+func (r Region) Contains(addr uint16) bool {
+	return addr >= r.Start && addr <= r.End
+}
+
 // The CPU structure
 type CPU struct {
 	// registers
@@ -73,6 +85,9 @@ type CPU struct {
 	Flags bits.Bitfield
 	// Memory
 	Memory []byte
+	// Memory map, reads outside ROM and RAM are bus errors
+	ROM []Region
+	RAM []Region
 	// Pointers
 	PC uint16
 	SP uint16
@@ -82,6 +97,10 @@ type CPU struct {
 
 	// keep control of cycles
 	Cycles uint32
+
+	// Memory access hooks for debuggers, nil when unused
+	OnRead  func(addr uint16)
+	OnWrite func(addr uint16)
 }
 
 // Disassembly a buffer
@@ -106,27 +125,46 @@ func New() CPU {
 	cpu.Memory = make([]byte, 0x10000) // 16Kb memory
 	cpu.PC = 0
 
+	cpu.ROM = []Region{{0x0000, RomOffset - 1}}
+	cpu.RAM = []Region{{RomOffset, RAMEnd}}
+
 	return cpu
 }
 
+// inRegions reports whether the address is mapped by any of the regions
+func inRegions(regions []Region, addr uint16) bool {
+	for _, r := range regions {
+		if r.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // MemRead reads byte from memory
 func (cpu *CPU) MemRead(offset uint16) uint8 {
-	if offset >= 0x4000 {
+	if !inRegions(cpu.ROM, offset) && !inRegions(cpu.RAM, offset) {
 		fmt.Printf("%04x\n", offset)
 		panic("Attempt to Read over the RAM limit")
 	}
+	if cpu.OnRead != nil {
+		cpu.OnRead(offset)
+	}
 	return cpu.Memory[offset]
 }
 
 // MemWrite writes byte to memory
 func (cpu *CPU) MemWrite(offset uint16, value uint8) {
-	if offset < RomOffset {
+	if inRegions(cpu.ROM, offset) {
 		fmt.Println(offset)
 		panic("Attempt to write ROM Memory")
 	}
 	// if offset >= 0x4000 {
 	//	// panic("Attempt to write over the RAM limit")
 	// }
+	if cpu.OnWrite != nil {
+		cpu.OnWrite(offset)
+	}
 	cpu.Memory[offset] = value
 }
 
@@ -138,11 +176,7 @@ func (cpu *CPU) NextWord() uint16 {
 
 // NextByte from cpu memory at pc
 func (cpu *CPU) NextByte() uint8 {
-	if cpu.PC >= 0x4000 {
-		fmt.Printf("%04x\n", cpu.PC)
-		panic("Attempt to Read over the RAM limit")
-	}
-	return cpu.Memory[cpu.PC]
+	return cpu.MemRead(cpu.PC)
 }
 
 // push a word to stack
@@ -0,0 +1,223 @@
+// Package env wraps the machine into a Gym style environment for training
+// agents on Space Invaders
+package env
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+
+	"github.com/protoshark/invaders8080/gamestate"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// Action of the agent, held for the frames of a step
+type Action int
+
+// Actions
+const (
+	Noop Action = iota
+	Fire
+	Left
+	Right
+	LeftFire
+	RightFire
+)
+
+var actionNames = [...]string{"noop", "fire", "left", "right", "left-fire", "right-fire"}
+
+func (action Action) String() string {
+	return actionNames[action]
+}
+
+// Actions available to the agent
+func Actions() []Action {
+	actions := make([]Action, len(actionNames))
+	for i := range actions {
+		actions[i] = Action(i)
+	}
+	return actions
+}
+
+// controls held for the action
+func (action Action) controls() invaders.Controls {
+	var controls invaders.Controls
+	controls.Set(invaders.P1Fire, action == Fire || action == LeftFire || action == RightFire)
+	controls.Set(invaders.P1Left, action == Left || action == LeftFire)
+	controls.Set(invaders.P1Right, action == Right || action == RightFire)
+	return controls
+}
+
+// Observation is the screen in grayscale, row by row
+type Observation struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Pixels []byte `json:"pixels"`
+}
+
+// Options of the environment
+type Options struct {
+	FrameSkip  int     // frames emulated per step, the action held for all of them
+	Downsample int     // the observation is the screen shrunk by this factor
+	Sticky     float64 // probability the previous action is kept for a frame
+	Seed       int64   // of the sticky actions
+}
+
+// DefaultOptions follow the usual Atari setup
+var DefaultOptions = Options{FrameSkip: 4, Downsample: 2, Sticky: 0.25}
+
+// frames allowed for the game to start after the coin
+const startTimeout = 60 * 30
+
+// Env is an environment running a game of Space Invaders from the start
+// every episode
+type Env struct {
+	machine *invaders.Machine
+	options Options
+	rng     *rand.Rand
+
+	// state right after a game is started
+	start *invaders.Snapshot
+
+	last  Action
+	score int
+	frame *image.RGBA
+}
+
+// New environment on the machine, which must have its ROM loaded
+func New(machine *invaders.Machine, options Options) (*Env, error) {
+	switch {
+	case options.FrameSkip < 1:
+		return nil, fmt.Errorf("frame skip must be at least 1")
+	case options.Downsample < 1:
+		return nil, fmt.Errorf("downsample must be at least 1")
+	case options.Sticky < 0 || options.Sticky >= 1:
+		return nil, fmt.Errorf("sticky probability must be in [0, 1)")
+	}
+
+	env := &Env{
+		machine: machine,
+		options: options,
+		rng:     rand.New(rand.NewSource(options.Seed)),
+	}
+	if err := env.startGame(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// power up, insert a coin and start a one player game, then keep the state
+// to start every episode from
+func (env *Env) startGame() error {
+	machine := env.machine
+	machine.Reset()
+
+	press := func(input invaders.Input, frames int) {
+		var controls invaders.Controls
+		controls.Set(input, true)
+		for i := 0; i < frames; i++ {
+			machine.StepFrame(controls)
+		}
+	}
+
+	env.idle(120)
+	press(invaders.Coin, 5)
+	env.idle(60)
+	press(invaders.P1Start, 5)
+
+	for i := 0; ; i++ {
+		if state := gamestate.Decode(machine); state.Playing && state.Ship.Alive {
+			break
+		}
+		if i == startTimeout {
+			return fmt.Errorf("the game did not start, check the ROM")
+		}
+		env.frame, _ = machine.StepFrame(0)
+	}
+
+	env.start = machine.Snapshot()
+	return nil
+}
+
+// run frames with nothing pressed
+func (env *Env) idle(frames int) {
+	for i := 0; i < frames; i++ {
+		env.frame, _ = env.machine.StepFrame(0)
+	}
+}
+
+// Reset starts a new episode
+func (env *Env) Reset() (Observation, error) {
+	if err := env.machine.Restore(env.start); err != nil {
+		return Observation{}, err
+	}
+	env.frame, _ = env.machine.StepFrame(0)
+	env.last = Noop
+	env.score = env.readScore()
+	return env.observe(), nil
+}
+
+// Step holds the action for the frame skip, the reward is the points scored
+// meanwhile and done tells the game is over, or the last ship is lost while
+// it blows up
+func (env *Env) Step(action Action) (Observation, float64, bool) {
+	reward := 0
+	done := false
+
+	for i := 0; i < env.options.FrameSkip && !done; i++ {
+		// sticky actions keep the previous one at random, like a human
+		// reacting late
+		if env.rng.Float64() >= env.options.Sticky {
+			env.last = action
+		}
+		env.frame, _ = env.machine.StepFrame(env.last.controls())
+
+		score := env.readScore()
+		delta := score - env.score
+		if delta < 0 {
+			delta += 10000 // the counter rolls over
+		}
+		reward += delta
+		env.score = score
+
+		state := gamestate.Decode(env.machine)
+		done = !state.Playing || state.Players[0].Ships == 0
+	}
+
+	return env.observe(), float64(reward), done
+}
+
+// Lives left to player 1
+func (env *Env) Lives() int {
+	return gamestate.Decode(env.machine).Players[0].Ships
+}
+
+// score of player 1
+func (env *Env) readScore() int {
+	return gamestate.Score(env.machine, gamestate.P1ScoreAddr)
+}
+
+// the screen in grayscale, each pixel the average of a square of the frame
+func (env *Env) observe() Observation {
+	n := env.options.Downsample
+	bounds := env.frame.Rect
+	obs := Observation{Width: bounds.Dx() / n, Height: bounds.Dy() / n}
+	obs.Pixels = make([]byte, obs.Width*obs.Height)
+
+	for y := 0; y < obs.Height; y++ {
+		for x := 0; x < obs.Width; x++ {
+			sum := 0
+			for dy := 0; dy < n; dy++ {
+				row := (y*n + dy) * env.frame.Stride
+				for dx := 0; dx < n; dx++ {
+					p := env.frame.Pix[row+(x*n+dx)*4:]
+					// Rec. 601 luma
+					sum += (299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000
+				}
+			}
+			obs.Pixels[y*obs.Width+x] = byte(sum / (n * n))
+		}
+	}
+
+	return obs
+}
@@ -0,0 +1,207 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/protoshark/invaders8080/cpu"
+	"github.com/protoshark/invaders8080/gamestate"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// program standing in for the game: it starts a game of 3 ships right away
+// and loops, the tests play by poking the RAM
+var testProgram = []byte{
+	0x3e, 0x01, 0x32, 0xef, 0x20, // MVI A, 1; STA 20ef, game mode
+	0x3e, 0xff, 0x32, 0x15, 0x20, // MVI A, ff; STA 2015, ship alive
+	0x3e, 0x03, 0x32, 0xff, 0x21, // MVI A, 3; STA 21ff, ships of player 1
+	0xc3, 0x0f, 0x00, // JMP $
+}
+
+func newTestMachine(t *testing.T) *invaders.Machine {
+	t.Helper()
+	machine, err := invaders.NewMachine(invaders.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range testProgram {
+		machine.Poke(uint16(i), b)
+	}
+	machine.SetOverlays(nil)
+	return machine
+}
+
+func newTestEnv(t *testing.T, options Options) *Env {
+	t.Helper()
+	env, err := New(newTestMachine(t), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func setScore(env *Env, score int) {
+	for i, b := range gamestate.ScoreBytes(score) {
+		env.machine.Poke(gamestate.P1ScoreAddr+uint16(i), b)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		err     string
+	}{
+		{"default", DefaultOptions, ""},
+		{"no frame", Options{FrameSkip: 0, Downsample: 1}, "frame skip must be at least 1"},
+		{"no downsample", Options{FrameSkip: 1, Downsample: 0}, "downsample must be at least 1"},
+		{"negative sticky", Options{FrameSkip: 1, Downsample: 1, Sticky: -0.1}, "sticky probability must be in [0, 1)"},
+		{"always sticky", Options{FrameSkip: 1, Downsample: 1, Sticky: 1}, "sticky probability must be in [0, 1)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(newTestMachine(t), test.options)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.err != "" && (err == nil || err.Error() != test.err):
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestStepReward(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		reward   float64
+	}{
+		{"nothing scored", 120, 120, 0},
+		{"first points", 0, 30, 30},
+		{"saucer", 1230, 1530, 300},
+		{"rollover", 9990, 20, 30},
+		{"rollover to zero", 9950, 0, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := newTestEnv(t, Options{FrameSkip: 4, Downsample: 1})
+			setScore(env, test.from)
+			env.Step(Noop)
+
+			setScore(env, test.to)
+			if _, reward, _ := env.Step(Fire); reward != test.reward {
+				t.Errorf("%d to %d: reward %g, want %g", test.from, test.to, reward, test.reward)
+			}
+			if _, reward, _ := env.Step(Fire); reward != 0 {
+				t.Errorf("reward %g counted again", reward)
+			}
+		})
+	}
+}
+
+func TestResetAfterGameOver(t *testing.T) {
+	env := newTestEnv(t, Options{FrameSkip: 1, Downsample: 1})
+
+	setScore(env, 500)
+	if _, reward, done := env.Step(Noop); reward != 500 || done {
+		t.Fatalf("got reward %g, done %v, want 500 and not done", reward, done)
+	}
+	env.machine.Poke(gamestate.GameModeAddr, 0)
+	if _, _, done := env.Step(Noop); !done {
+		t.Fatalf("game over not done")
+	}
+
+	// the episode starts over from no points, the score going down is not
+	// taken for a rollover
+	if _, err := env.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, reward, done := env.Step(Noop); reward != 0 || done {
+		t.Errorf("after reset: got reward %g, done %v, want 0 and not done", reward, done)
+	}
+	if lives := env.Lives(); lives != 3 {
+		t.Errorf("%d lives after reset, want 3", lives)
+	}
+}
+
+func TestStepDone(t *testing.T) {
+	tests := []struct {
+		name     string
+		gameMode uint8
+		ships    uint8
+		done     bool
+	}{
+		{"playing", 1, 3, false},
+		{"last ship", 1, 1, false},
+		{"last ship lost", 1, 0, true},
+		{"game over", 0, 2, true},
+		{"game over, no ship", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := newTestEnv(t, Options{FrameSkip: 4, Downsample: 1})
+			env.machine.Poke(gamestate.GameModeAddr, test.gameMode)
+			env.machine.Poke(0x21ff, test.ships)
+
+			if _, _, done := env.Step(Left); done != test.done {
+				t.Errorf("done %v, want %v", done, test.done)
+			}
+			if lives := env.Lives(); lives != int(test.ships) {
+				t.Errorf("%d lives, want %d", lives, test.ships)
+			}
+		})
+	}
+}
+
+func TestObservation(t *testing.T) {
+	type pixel struct {
+		x, y  int
+		value byte
+	}
+
+	// every column lights the 4 lower pixels of each group of 8 rows
+	tests := []struct {
+		downsample    int
+		width, height int
+		pixels        []pixel
+	}{
+		{1, 224, 256, []pixel{{0, 0, 0x00}, {0, 3, 0x00}, {0, 4, 0xff}, {223, 255, 0xff}}},
+		{2, 112, 128, []pixel{{0, 0, 0x00}, {0, 2, 0xff}, {111, 3, 0xff}, {5, 4, 0x00}}},
+		{3, 74, 85, nil},
+		{8, 28, 32, []pixel{{0, 0, 0x7f}, {27, 31, 0x7f}}},
+	}
+
+	for _, test := range tests {
+		env := newTestEnv(t, Options{FrameSkip: 1, Downsample: test.downsample})
+		for addr := cpu.VRAMOffset; addr <= cpu.RAMEnd; addr++ {
+			env.machine.Poke(uint16(addr), 0x0f)
+		}
+
+		obs, _, _ := env.Step(Noop)
+		if obs.Width != test.width || obs.Height != test.height || len(obs.Pixels) != test.width*test.height {
+			t.Errorf("downsample %d: %dx%d with %d pixels, want %dx%d", test.downsample,
+				obs.Width, obs.Height, len(obs.Pixels), test.width, test.height)
+			continue
+		}
+		for _, p := range test.pixels {
+			if got := obs.Pixels[p.y*obs.Width+p.x]; got != p.value {
+				t.Errorf("downsample %d: pixel %d,%d is %02x, want %02x", test.downsample, p.x, p.y, got, p.value)
+			}
+		}
+
+		// Reset gives the same shape
+		obs, err := env.Reset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if obs.Width != test.width || obs.Height != test.height || len(obs.Pixels) != test.width*test.height {
+			t.Errorf("downsample %d: reset to %dx%d", test.downsample, obs.Width, obs.Height)
+		}
+	}
+}
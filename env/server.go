@@ -0,0 +1,102 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// Request of a client, one JSON object per line
+//
+//	{"cmd": "actions"}
+//	{"cmd": "reset"}
+//	{"cmd": "step", "action": 1}
+type Request struct {
+	Cmd    string `json:"cmd"`
+	Action Action `json:"action"`
+}
+
+// Response to a request, one JSON object per line. The pixels of the
+// observation are base64 encoded
+type Response struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Reward      float64      `json:"reward"`
+	Done        bool         `json:"done"`
+	Lives       int          `json:"lives"`
+	Actions     []string     `json:"actions,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Serve environments on the listener, each connection gets its own from
+// newEnv so clients can train in parallel
+func Serve(listener net.Listener, newEnv func() (*Env, error)) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(conn, newEnv)
+	}
+}
+
+func serveConn(conn net.Conn, newEnv func() (*Env, error)) {
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	enc := json.NewEncoder(w)
+	respond := func(resp Response) error {
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	env, err := newEnv()
+	if err != nil {
+		respond(Response{Error: err.Error()})
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			if respond(Response{Error: err.Error()}) != nil {
+				return
+			}
+			continue
+		}
+
+		if err := respond(env.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (env *Env) handle(req Request) Response {
+	switch req.Cmd {
+	case "actions":
+		var names []string
+		for _, action := range Actions() {
+			names = append(names, action.String())
+		}
+		return Response{Actions: names}
+
+	case "reset":
+		obs, err := env.Reset()
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Observation: &obs, Lives: env.Lives()}
+
+	case "step":
+		if req.Action < 0 || int(req.Action) >= len(actionNames) {
+			return Response{Error: fmt.Sprintf("unknown action %d", req.Action)}
+		}
+		obs, reward, done := env.Step(req.Action)
+		return Response{Observation: &obs, Reward: reward, Done: done, Lives: env.Lives()}
+	}
+
+	return Response{Error: fmt.Sprintf("unknown command %q", req.Cmd)}
+}
@@ -0,0 +1,143 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// listener handing out the server ends of pipes
+type pipeListener chan net.Conn
+
+func (l pipeListener) Accept() (net.Conn, error) {
+	conn, ok := <-l
+	if !ok {
+		return nil, errors.New("listener closed")
+	}
+	return conn, nil
+}
+
+func (l pipeListener) Close() error   { close(l); return nil }
+func (l pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+// connect a client to a server of the environments
+func dial(t *testing.T, newEnv func() (*Env, error)) (net.Conn, *json.Decoder) {
+	t.Helper()
+	listener := make(pipeListener)
+	served := make(chan error, 1)
+	go func() { served <- Serve(listener, newEnv) }()
+
+	client, server := net.Pipe()
+	listener <- server
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+		if err := <-served; err == nil || err.Error() != "listener closed" {
+			t.Errorf("Serve returned %v", err)
+		}
+	})
+	return client, json.NewDecoder(bufio.NewReader(client))
+}
+
+func TestServe(t *testing.T) {
+	options := Options{FrameSkip: 2, Downsample: 2}
+	client, dec := dial(t, func() (*Env, error) {
+		return New(newTestMachine(t), options)
+	})
+
+	tests := []struct {
+		request string
+		check   func(resp Response) error
+	}{
+		{`{"cmd": "actions"}`, func(resp Response) error {
+			want := []string{"noop", "fire", "left", "right", "left-fire", "right-fire"}
+			if !reflect.DeepEqual(resp.Actions, want) {
+				return fmt.Errorf("actions %v, want %v", resp.Actions, want)
+			}
+			return nil
+		}},
+		{`{"cmd": "reset"}`, func(resp Response) error {
+			return checkObservation(resp, 3, false)
+		}},
+		{`{"cmd": "step", "action": 5}`, func(resp Response) error {
+			return checkObservation(resp, 3, false)
+		}},
+		{`{"cmd": "step", "action": 6}`, errorResponse("unknown action 6")},
+		{`{"cmd": "step", "action": -1}`, errorResponse("unknown action -1")},
+		{`{"cmd": "jump"}`, errorResponse(`unknown command "jump"`)},
+		{`{}`, errorResponse(`unknown command ""`)},
+		{`{"cmd": "step"`, errorResponse("unexpected end of JSON input")},
+		{`{"cmd": "step", "action": "fire"}`, errorResponse("json: cannot unmarshal string into Go struct field Request.action of type env.Action")},
+		// the connection goes on after bad requests
+		{`{"cmd": "step", "action": 0}`, func(resp Response) error {
+			return checkObservation(resp, 3, false)
+		}},
+	}
+
+	for _, test := range tests {
+		if _, err := fmt.Fprintln(client, test.request); err != nil {
+			t.Fatal(err)
+		}
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("%s: %v", test.request, err)
+		}
+		if err := test.check(resp); err != nil {
+			t.Errorf("%s: %v", test.request, err)
+		}
+	}
+}
+
+func TestServeEnvError(t *testing.T) {
+	_, dec := dial(t, func() (*Env, error) {
+		return nil, errors.New("the game did not start, check the ROM")
+	})
+
+	var resp Response
+	if err := dec.Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "the game did not start, check the ROM" {
+		t.Errorf("got error %q", resp.Error)
+	}
+
+	// and the connection is closed
+	if err := dec.Decode(&resp); err == nil {
+		t.Errorf("connection still open")
+	}
+}
+
+func checkObservation(resp Response, lives int, done bool) error {
+	switch obs := resp.Observation; {
+	case resp.Error != "":
+		return fmt.Errorf("error %q", resp.Error)
+	case obs == nil:
+		return fmt.Errorf("no observation")
+	case obs.Width != 112 || obs.Height != 128 || len(obs.Pixels) != 112*128:
+		return fmt.Errorf("observation %dx%d with %d pixels", obs.Width, obs.Height, len(obs.Pixels))
+	case resp.Lives != lives || resp.Done != done:
+		return fmt.Errorf("lives %d, done %v, want %d, %v", resp.Lives, resp.Done, lives, done)
+	}
+	return nil
+}
+
+func errorResponse(msg string) func(resp Response) error {
+	return func(resp Response) error {
+		if resp.Error != msg {
+			return fmt.Errorf("error %q, want %q", resp.Error, msg)
+		}
+		if resp.Observation != nil {
+			return fmt.Errorf("observation with the error")
+		}
+		return nil
+	}
+}
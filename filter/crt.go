@@ -0,0 +1,210 @@
+package filter
+
+import (
+	"image"
+)
+
+// Scale the frame up by an integer factor, nearest neighbour. The filters
+// after it then work with more than one output pixel per emulated pixel
+type Scale struct {
+	Factor int
+
+	out *image.RGBA
+}
+
+// Apply the filter
+func (f *Scale) Apply(src *image.RGBA) *image.RGBA {
+	if f.Factor <= 1 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	f.out = frame(f.out, w*f.Factor, h*f.Factor)
+
+	for y := 0; y < h*f.Factor; y++ {
+		srow := src.Pix[(y/f.Factor)*src.Stride:]
+		drow := f.out.Pix[y*f.out.Stride:]
+		for x := 0; x < w*f.Factor; x++ {
+			copy(drow[x*4:x*4+4], srow[(x/f.Factor)*4:])
+		}
+	}
+
+	return f.out
+}
+
+// Scanlines darkens every other line by Strength
+type Scanlines struct {
+	Strength float64
+
+	out *image.RGBA
+}
+
+// Apply the filter
+func (f *Scanlines) Apply(src *image.RGBA) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	f.out = frame(f.out, w, h)
+	copy(f.out.Pix, src.Pix)
+
+	keep := 1 - f.Strength
+	for y := 1; y < h; y += 2 {
+		row := f.out.Pix[y*f.out.Stride : y*f.out.Stride+w*4]
+		for i := 0; i < len(row); i += 4 {
+			row[i] = uint8(float64(row[i]) * keep)
+			row[i+1] = uint8(float64(row[i+1]) * keep)
+			row[i+2] = uint8(float64(row[i+2]) * keep)
+		}
+	}
+
+	return f.out
+}
+
+// Ghost keeps a fading copy of the previous frames, every frame the
+// previous output is multiplied by Decay and the brightest wins
+type Ghost struct {
+	Decay float64
+
+	out *image.RGBA
+}
+
+// Apply the filter
+func (f *Ghost) Apply(src *image.RGBA) *image.RGBA {
+	f.out = frame(f.out, src.Rect.Dx(), src.Rect.Dy())
+
+	// decay in 1/256
+	decay := uint32(f.Decay * 256)
+	for i := range f.out.Pix {
+		if i%4 == 3 {
+			f.out.Pix[i] = 0xff
+			continue
+		}
+
+		faded := uint8(uint32(f.out.Pix[i]) * decay >> 8)
+		if v := src.Pix[i]; v > faded {
+			f.out.Pix[i] = v
+		} else {
+			f.out.Pix[i] = faded
+		}
+	}
+
+	return f.out
+}
+
+// Bloom adds a blurred copy of the frame around lit pixels
+type Bloom struct {
+	Strength float64
+	Radius   int
+
+	tmp  []int32
+	blur []int32
+	out  *image.RGBA
+}
+
+// Apply the filter
+func (f *Bloom) Apply(src *image.RGBA) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	f.out = frame(f.out, w, h)
+	if len(f.blur) != w*h*4 {
+		f.tmp = make([]int32, w*h*4)
+		f.blur = make([]int32, w*h*4)
+	}
+
+	// separable box blur with running sums, horizontal then vertical. The
+	// sums are kept undivided and scaled once at the end
+	r := f.Radius
+	for y := 0; y < h; y++ {
+		row := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		out := f.tmp[y*w*4 : (y+1)*w*4]
+		var sum [3]int32
+		for x := 0; x <= r && x < w; x++ {
+			for c := 0; c < 3; c++ {
+				sum[c] += int32(row[x*4+c])
+			}
+		}
+		for x := 0; x < w; x++ {
+			for c := 0; c < 3; c++ {
+				out[x*4+c] = sum[c]
+				if in := x + r + 1; in < w {
+					sum[c] += int32(row[in*4+c])
+				}
+				if gone := x - r; gone >= 0 {
+					sum[c] -= int32(row[gone*4+c])
+				}
+			}
+		}
+	}
+	sum := make([]int32, w*4)
+	for y := 0; y <= r && y < h; y++ {
+		for i, v := range f.tmp[y*w*4 : (y+1)*w*4] {
+			sum[i] += v
+		}
+	}
+	for y := 0; y < h; y++ {
+		copy(f.blur[y*w*4:(y+1)*w*4], sum)
+		if in := y + r + 1; in < h {
+			for i, v := range f.tmp[in*w*4 : (in+1)*w*4] {
+				sum[i] += v
+			}
+		}
+		if gone := y - r; gone >= 0 {
+			for i, v := range f.tmp[gone*w*4 : (gone+1)*w*4] {
+				sum[i] -= v
+			}
+		}
+	}
+
+	// strength in 1/256 over the blur window area
+	norm := int32(2*r+1) * int32(2*r+1)
+	strength := int32(f.Strength * 256)
+	for y := 0; y < h; y++ {
+		srow := src.Pix[y*src.Stride : y*src.Stride+w*4]
+		drow := f.out.Pix[y*f.out.Stride : y*f.out.Stride+w*4]
+		blur := f.blur[y*w*4 : (y+1)*w*4]
+		for i := 0; i < w*4; i += 4 {
+			for c := 0; c < 3; c++ {
+				v := int32(srow[i+c]) + blur[i+c]*strength/norm>>8
+				if v > 255 {
+					v = 255
+				}
+				drow[i+c] = uint8(v)
+			}
+			drow[i+3] = 0xff
+		}
+	}
+
+	return f.out
+}
+
+// Curvature bends the frame like the bulge of a CRT tube, Amount is the
+// barrel distortion at the corners
+type Curvature struct {
+	Amount float64
+
+	out *image.RGBA
+}
+
+// Apply the filter
+func (f *Curvature) Apply(src *image.RGBA) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	f.out = frame(f.out, w, h)
+
+	for y := 0; y < h; y++ {
+		// normalized to -1..1 from the centre
+		ny := float64(2*y+1)/float64(h) - 1
+		for x := 0; x < w; x++ {
+			nx := float64(2*x+1)/float64(w) - 1
+
+			k := 1 + f.Amount*(nx*nx+ny*ny)
+			sx := int((nx*k + 1) * float64(w) / 2)
+			sy := int((ny*k + 1) * float64(h) / 2)
+
+			d := y*f.out.Stride + x*4
+			if sx < 0 || sx >= w || sy < 0 || sy >= h {
+				f.out.Pix[d], f.out.Pix[d+1], f.out.Pix[d+2], f.out.Pix[d+3] = 0, 0, 0, 0xff
+				continue
+			}
+			copy(f.out.Pix[d:d+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+
+	return f.out
+}
@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// Filter post-processes a frame
+type Filter interface {
+	// Apply returns the processed frame, it may be src itself. The returned
+	// frame is only valid until the next call
+	Apply(src *image.RGBA) *image.RGBA
+}
+
+// Chain applies its filters in order
+type Chain []Filter
+
+// Apply every filter of the chain
+func (chain Chain) Apply(src *image.RGBA) *image.RGBA {
+	for _, filter := range chain {
+		src = filter.Apply(src)
+	}
+	return src
+}
+
+// filter constructors by name, taking the optional parameter
+var constructors = map[string]struct {
+	def float64
+	new func(param float64) (Filter, error)
+}{
+	"scale": {3, func(p float64) (Filter, error) {
+		if p < 1 || p > 8 || p != float64(int(p)) {
+			return nil, fmt.Errorf("scale must be an integer between 1 and 8")
+		}
+		return &Scale{Factor: int(p)}, nil
+	}},
+	"scanlines": {0.5, func(p float64) (Filter, error) {
+		return &Scanlines{Strength: p}, checkUnit(p)
+	}},
+	"ghost": {0.6, func(p float64) (Filter, error) {
+		return &Ghost{Decay: p}, checkUnit(p)
+	}},
+	"bloom": {0.5, func(p float64) (Filter, error) {
+		return &Bloom{Strength: p, Radius: 2}, checkUnit(p)
+	}},
+	"curvature": {0.08, func(p float64) (Filter, error) {
+		if !(p >= 0 && p <= 0.5) {
+			return nil, fmt.Errorf("curvature must be between 0 and 0.5")
+		}
+		return &Curvature{Amount: p}, nil
+	}},
+}
+
+func checkUnit(p float64) error {
+	if !(p >= 0 && p <= 1) { // NaN included
+		return fmt.Errorf("strength must be between 0 and 1")
+	}
+	return nil
+}
+
+// Names of the available filters
+func Names() []string {
+	return []string{"scale", "scanlines", "ghost", "bloom", "curvature"}
+}
+
+// Parse a comma separated filter chain, each filter written as name or
+// name=parameter, e.g. "scale=3,scanlines=0.4,bloom"
+func Parse(spec string) (Chain, error) {
+	var chain Chain
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value := item, ""
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			name, value = item[:i], item[i+1:]
+		}
+
+		constructor, ok := constructors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q, available: %s", name, strings.Join(Names(), ", "))
+		}
+
+		param := constructor.def
+		if value != "" {
+			var err error
+			if param, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+		}
+
+		filter, err := constructor.new(param)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", name, err)
+		}
+		chain = append(chain, filter)
+	}
+
+	return chain, nil
+}
+
+// reuse buf when it has the wanted size
+func frame(buf *image.RGBA, w, h int) *image.RGBA {
+	if buf == nil || buf.Rect.Dx() != w || buf.Rect.Dy() != h {
+		return image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	return buf
+}
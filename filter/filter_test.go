@@ -0,0 +1,59 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec  string
+		chain Chain
+		err   string
+	}{
+		{"", nil, ""},
+		{" , ,", nil, ""},
+		{"scale", Chain{&Scale{Factor: 3}}, ""},
+		{
+			"scale=2, scanlines=0.4,ghost,bloom=1,curvature=0",
+			Chain{&Scale{Factor: 2}, &Scanlines{Strength: 0.4}, &Ghost{Decay: 0.6}, &Bloom{Strength: 1, Radius: 2}, &Curvature{Amount: 0}},
+			"",
+		},
+		{"scanlines=", Chain{&Scanlines{Strength: 0.5}}, ""},
+		{"scanlines,scanlines=0.2", Chain{&Scanlines{Strength: 0.5}, &Scanlines{Strength: 0.2}}, ""},
+		{"blur", nil, `unknown filter "blur", available: scale, scanlines, ghost, bloom, curvature`},
+		{"=2", nil, `unknown filter "", available: scale, scanlines, ghost, bloom, curvature`},
+		{"scale=big", nil, `filter scale: strconv.ParseFloat: parsing "big": invalid syntax`},
+		{"scale=0", nil, "filter scale: scale must be an integer between 1 and 8"},
+		{"scale=9", nil, "filter scale: scale must be an integer between 1 and 8"},
+		{"scale=2.5", nil, "filter scale: scale must be an integer between 1 and 8"},
+		{"scale=NaN", nil, "filter scale: scale must be an integer between 1 and 8"},
+		{"scanlines=1.5", nil, "filter scanlines: strength must be between 0 and 1"},
+		{"ghost=-0.1", nil, "filter ghost: strength must be between 0 and 1"},
+		{"bloom=NaN", nil, "filter bloom: strength must be between 0 and 1"},
+		{"curvature=0.6", nil, "filter curvature: curvature must be between 0 and 0.5"},
+		{"curvature=NaN", nil, "filter curvature: curvature must be between 0 and 0.5"},
+		{"scale,blur", nil, `unknown filter "blur", available: scale, scanlines, ghost, bloom, curvature`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			chain, err := Parse(test.spec)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				if chain != nil {
+					t.Errorf("got a chain %v with the error", chain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(chain, test.chain) {
+				t.Errorf("got %v, want %v", chain, test.chain)
+			}
+		})
+	}
+}
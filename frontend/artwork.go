@@ -0,0 +1,206 @@
+package frontend
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // backgrounds are often photos
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Layout places the game screen among the cabinet artwork
+type Layout struct {
+	Size   image.Point
+	Screen image.Rectangle
+
+	// image files, the background shows through the screen and the bezel
+	// is drawn over everything
+	Background     string
+	BackgroundRect image.Rectangle
+	Bezel          string
+	BezelRect      image.Rectangle
+}
+
+// artwork textures
+type artwork struct {
+	layout     *Layout
+	background *sdl.Texture
+	bezel      *sdl.Texture
+}
+
+// LoadLayout reads a layout file, one directive per line
+//
+//	size width height
+//	screen x y width height
+//	background image [x y width height]
+//	bezel image [x y width height]
+//
+// images are relative to the layout file and stretched over the whole
+// layout when no rectangle is given
+func LoadLayout(path string) (*Layout, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	layout := &Layout{}
+	dir := filepath.Dir(path)
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+		switch directive, args := fields[0], fields[1:]; directive {
+		case "size":
+			layout.Size, err = parseLayoutSize(args)
+		case "screen":
+			layout.Screen, err = parseLayoutRect(args)
+		case "background":
+			layout.Background, layout.BackgroundRect, err = parseLayoutImage(dir, args)
+		case "bezel":
+			layout.Bezel, layout.BezelRect, err = parseLayoutImage(dir, args)
+		default:
+			err = fmt.Errorf("unknown directive %q", directive)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if layout.Size.X <= 0 || layout.Size.Y <= 0 {
+		return nil, fmt.Errorf("%s: missing layout size", path)
+	}
+	if layout.Screen.Empty() {
+		return nil, fmt.Errorf("%s: missing screen rectangle", path)
+	}
+
+	whole := image.Rectangle{Max: layout.Size}
+	if layout.BackgroundRect.Empty() {
+		layout.BackgroundRect = whole
+	}
+	if layout.BezelRect.Empty() {
+		layout.BezelRect = whole
+	}
+
+	return layout, nil
+}
+
+func parseLayoutSize(args []string) (image.Point, error) {
+	var size image.Point
+	if len(args) != 2 {
+		return image.Point{}, fmt.Errorf("expected width height")
+	}
+	if _, err := fmt.Sscan(strings.Join(args, " "), &size.X, &size.Y); err != nil {
+		return image.Point{}, err
+	}
+	return size, nil
+}
+
+func parseLayoutRect(args []string) (image.Rectangle, error) {
+	var x, y, w, h int
+	if len(args) != 4 {
+		return image.Rectangle{}, fmt.Errorf("expected x y width height")
+	}
+	if _, err := fmt.Sscan(strings.Join(args, " "), &x, &y, &w, &h); err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+func parseLayoutImage(dir string, args []string) (string, image.Rectangle, error) {
+	if len(args) != 1 && len(args) != 5 {
+		return "", image.Rectangle{}, fmt.Errorf("expected image [x y width height]")
+	}
+
+	path := args[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if len(args) == 1 {
+		return path, image.Rectangle{}, nil
+	}
+	rect, err := parseLayoutRect(args[1:])
+	return path, rect, err
+}
+
+// SetLayout composites the game with cabinet artwork, it must be called
+// before Run
+func (front *Frontend) SetLayout(layout *Layout) {
+	front.artwork = &artwork{layout: layout}
+}
+
+// create the artwork textures
+func (art *artwork) load(renderer *sdl.Renderer) error {
+	var err error
+	if art.layout.Background != "" {
+		if art.background, err = loadTexture(renderer, art.layout.Background); err != nil {
+			return err
+		}
+	}
+	if art.layout.Bezel != "" {
+		if art.bezel, err = loadTexture(renderer, art.layout.Bezel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (art *artwork) destroy() {
+	if art.background != nil {
+		art.background.Destroy()
+	}
+	if art.bezel != nil {
+		art.bezel.Destroy()
+	}
+	art.background, art.bezel = nil, nil
+}
+
+// decode an image file into a static texture
+func loadTexture(renderer *sdl.Renderer, path string) (*sdl.Texture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	texture, err := renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STATIC,
+		int32(bounds.Dx()), int32(bounds.Dy()))
+	if err != nil {
+		return nil, err
+	}
+	if err := texture.Update(nil, rgba.Pix, rgba.Stride); err != nil {
+		texture.Destroy()
+		return nil, err
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+
+	return texture, nil
+}
+
+func sdlRect(r image.Rectangle) *sdl.Rect {
+	return &sdl.Rect{X: int32(r.Min.X), Y: int32(r.Min.Y), W: int32(r.Dx()), H: int32(r.Dy())}
+}
@@ -0,0 +1,102 @@
+package frontend
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	at := func(name string) string { return filepath.Join(dir, name) }
+	abs := filepath.Join(string(filepath.Separator), "art", "bezel.png")
+
+	tests := []struct {
+		name   string
+		text   string
+		layout *Layout
+		err    string // after the path
+	}{
+		{
+			"whole images",
+			"# cabinet\n\nsize 640 480\nscreen 208 112 224 256\nbackground back.jpg\nbezel art/bezel.png\n",
+			&Layout{
+				Size:           image.Pt(640, 480),
+				Screen:         image.Rect(208, 112, 432, 368),
+				Background:     at("back.jpg"),
+				BackgroundRect: image.Rect(0, 0, 640, 480),
+				Bezel:          at("art/bezel.png"),
+				BezelRect:      image.Rect(0, 0, 640, 480),
+			},
+			"",
+		},
+		{
+			"placed images",
+			"size 640 480\nscreen 0 0 224 256\nbackground back.jpg 10 20 100 50\nbezel " + abs + " -10 -10 660 500\n",
+			&Layout{
+				Size:           image.Pt(640, 480),
+				Screen:         image.Rect(0, 0, 224, 256),
+				Background:     at("back.jpg"),
+				BackgroundRect: image.Rect(10, 20, 110, 70),
+				Bezel:          abs,
+				BezelRect:      image.Rect(-10, -10, 650, 490),
+			},
+			"",
+		},
+		{
+			"no images",
+			"size 224 256\nscreen 0 0 224 256",
+			&Layout{
+				Size:           image.Pt(224, 256),
+				Screen:         image.Rect(0, 0, 224, 256),
+				BackgroundRect: image.Rect(0, 0, 224, 256),
+				BezelRect:      image.Rect(0, 0, 224, 256),
+			},
+			"",
+		},
+		{"missing size", "screen 0 0 224 256", nil, ": missing layout size"},
+		{"empty size", "size 0 256\nscreen 0 0 224 256", nil, ": missing layout size"},
+		{"missing screen", "size 224 256", nil, ": missing screen rectangle"},
+		{"unknown directive", "size 224 256\nmarquee top.png", nil, `:2: unknown directive "marquee"`},
+		{"size extra field", "size 224 256 1", nil, ":1: expected width height"},
+		{"size not a number", "size wide 256", nil, ":1: expected integer"},
+		{"screen short", "screen 0 0 224", nil, ":1: expected x y width height"},
+		{"screen extra field", "screen 0 0 224 256 0", nil, ":1: expected x y width height"},
+		{"image rectangle short", "bezel b.png 0 0 10", nil, ":1: expected image [x y width height]"},
+		{"image missing", "background", nil, ":1: expected image [x y width height]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := at(filepath.Base(t.Name()) + ".layout")
+			if err := ioutil.WriteFile(path, []byte(test.text), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			layout, err := LoadLayout(path)
+			if test.err != "" {
+				if err == nil || err.Error() != path+test.err {
+					t.Errorf("got error %v, want %q", err, path+test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(layout, test.layout) {
+				t.Errorf("got %+v, want %+v", layout, test.layout)
+			}
+		})
+	}
+
+	if _, err := LoadLayout(at("missing.layout")); err == nil {
+		t.Errorf("missing file loaded")
+	}
+}
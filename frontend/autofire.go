@@ -0,0 +1,32 @@
+package frontend
+
+import "github.com/protoshark/invaders8080/invaders"
+
+// fire buttons repeated by autofire
+var autofireInputs = map[invaders.Input]bool{invaders.P1Fire: true, invaders.P2Fire: true}
+
+// SetAutofire repeats the fire buttons while held, pressed for frames
+// emulated frames then released for as many, 0 turns it off
+func (front *Frontend) SetAutofire(frames int) {
+	front.autofireRate = frames
+	front.autofireHeld = make(map[invaders.Input]int)
+}
+
+// the held fire buttons pressed or released for the frame about to run
+func (front *Frontend) stepAutofire(controls invaders.Controls) invaders.Controls {
+	if front.autofireRate <= 0 {
+		return controls
+	}
+
+	for input := range autofireInputs {
+		if !controls.Pressed(input) {
+			delete(front.autofireHeld, input)
+			continue
+		}
+
+		frames := front.autofireHeld[input]
+		controls.Set(input, frames/front.autofireRate%2 == 0)
+		front.autofireHeld[input] = frames + 1
+	}
+	return controls
+}
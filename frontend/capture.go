@@ -0,0 +1,133 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/protoshark/invaders8080/capture"
+)
+
+// SetCaptureDir sets where the hotkeys save screenshots and recordings
+func (front *Frontend) SetCaptureDir(dir string) {
+	front.captureDir = dir
+}
+
+// Screenshot saves the last frame shown, with the overlay, rotation and
+// filters applied, as a PNG file at the scale of the screen in the window
+func (front *Frontend) Screenshot(path string) error {
+	if front.lastFrame == nil {
+		return fmt.Errorf("no frame shown yet")
+	}
+	return capture.SavePNG(path, capture.Scale(front.lastFrame, front.windowScale()))
+}
+
+// StartRecording the frames shown into an animated GIF file, at the scale
+// of the screen in the window
+func (front *Frontend) StartRecording(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	front.recorder = capture.NewGIFRecorder(file, front.machine.Config().FrameRate(), front.windowScale())
+	front.recordFile = file
+	return nil
+}
+
+// StopRecording and close the animation
+func (front *Frontend) StopRecording() error {
+	if front.recorder == nil {
+		return fmt.Errorf("not recording")
+	}
+
+	recorder, file := front.recorder, front.recordFile
+	front.recorder, front.recordFile = nil, nil
+
+	if err := recorder.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Recording reports whether frames are being recorded
+func (front *Frontend) Recording() bool {
+	return front.recorder != nil
+}
+
+// record the frame shown, a failed recording is stopped
+func (front *Frontend) recordFrame(frame *image.RGBA) {
+	if front.recorder == nil {
+		return
+	}
+	if err := front.recorder.AddFrame(frame); err != nil {
+		fmt.Printf("Recording failed: %s\n", err)
+		front.StopRecording()
+	}
+}
+
+// whole times a pixel of the frame shown is enlarged in the window, at
+// least 1
+func (front *Frontend) windowScale() int {
+	if front.renderer == nil || front.textureSize.X == 0 {
+		return 1
+	}
+	ow, oh, err := front.renderer.GetOutputSize()
+	if err != nil {
+		return 1
+	}
+
+	// the frame fills the screen of the layout, the window shows the
+	// layout whole
+	screen := image.Rectangle{Max: front.logicalSize}
+	if front.artwork != nil {
+		screen = front.artwork.layout.Screen
+	}
+	sx := float64(ow) / float64(front.logicalSize.X) * float64(screen.Dx()) / float64(front.textureSize.X)
+	sy := float64(oh) / float64(front.logicalSize.Y) * float64(screen.Dy()) / float64(front.textureSize.Y)
+
+	if scale := int(math.Min(sx, sy)); scale > 1 {
+		return scale
+	}
+	return 1
+}
+
+// file name for a capture taken now
+func (front *Frontend) capturePath(ext string) string {
+	name := fmt.Sprintf("%s-%s.%s", front.machine.Profile().Name, time.Now().Format("20060102-150405"), ext)
+	return filepath.Join(front.captureDir, name)
+}
+
+// save a screenshot from the hotkey
+func (front *Frontend) screenshotHotkey() {
+	path := front.capturePath("png")
+	if err := front.Screenshot(path); err != nil {
+		fmt.Printf("Screenshot failed: %s\n", err)
+		return
+	}
+	fmt.Printf("Saved %s\n", path)
+}
+
+// start or stop recording from the hotkey
+func (front *Frontend) recordHotkey() {
+	if !front.Recording() {
+		path := front.capturePath("gif")
+		if err := front.StartRecording(path); err != nil {
+			fmt.Printf("Recording failed: %s\n", err)
+			return
+		}
+		fmt.Printf("Recording %s\n", path)
+		return
+	}
+
+	path := front.recordFile.Name()
+	if err := front.StopRecording(); err != nil {
+		fmt.Printf("Recording failed: %s\n", err)
+		return
+	}
+	fmt.Printf("Saved %s\n", path)
+}
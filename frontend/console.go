@@ -0,0 +1,236 @@
+package frontend
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/protoshark/invaders8080/cheat"
+	"github.com/protoshark/invaders8080/cpu"
+)
+
+// candidates printed by the console
+const listedCandidates = 20
+
+var consoleHelp = `commands, numbers in hexadecimal:
+  cheats                        list the cheats
+  on N, off N                   enable or disable cheat N
+  add ADDR VALUE COND [DESC]    add a cheat, COND is always, once or like 20ef=01
+  freeze ADDR VALUE [DESC]      add a cheat writing VALUE every frame
+  del N                         remove cheat N
+  poke ADDR VALUE               write a byte once
+  peek ADDR                     read a byte
+  save                          save the cheats of the ROM
+  search [START END]            start a RAM search, 2000-23ff by default
+  eq VALUE, changed, unchanged,
+  gt, lt                        keep the candidates passing the comparison
+  list                          list the candidates`
+
+// SetCheats sets the cheats applied before every frame, path is the file
+// the console saves them to
+func (front *Frontend) SetCheats(cheats []cheat.Cheat, path string) {
+	front.cheats, front.cheatPath = cheats, path
+}
+
+// StartConsole reads cheat and RAM search commands from r, a line each,
+// run between frames
+func (front *Frontend) StartConsole(r io.Reader) {
+	front.console = make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			front.console <- scanner.Text()
+		}
+	}()
+	fmt.Println("Console ready, type help for the commands")
+}
+
+// run the commands typed since the last frame
+func (front *Frontend) runConsole() {
+	for {
+		select {
+		case line := <-front.console:
+			if err := front.consoleCommand(strings.Fields(line)); err != nil {
+				fmt.Printf("%s\n", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (front *Frontend) consoleCommand(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	cmd, args := args[0], args[1:]
+
+	// the RAM written outside of the inputs would not replay the same
+	if front.inMovie() && (cmd == "poke" || cmd == "add" || cmd == "freeze") {
+		return fmt.Errorf("%s is not allowed while input is recorded or played back", cmd)
+	}
+
+	switch cmd {
+	case "help":
+		fmt.Println(consoleHelp)
+
+	case "cheats":
+		if len(front.cheats) == 0 {
+			fmt.Println("no cheats")
+		}
+		for i, c := range front.cheats {
+			printCheat(i+1, c)
+		}
+
+	case "on", "off", "del":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s N", cmd)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(front.cheats) {
+			return fmt.Errorf("no cheat %s", args[0])
+		}
+		switch cmd {
+		case "on":
+			front.cheats[n-1].Enabled = true
+		case "off":
+			front.cheats[n-1].Enabled = false
+		case "del":
+			front.cheats = append(front.cheats[:n-1], front.cheats[n:]...)
+		}
+
+	case "add", "freeze":
+		c, err := parseConsoleCheat(cmd, args)
+		if err != nil {
+			return err
+		}
+		front.cheats = append(front.cheats, c)
+		printCheat(len(front.cheats), c)
+
+	case "poke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: poke ADDR VALUE")
+		}
+		addr, err := cheat.ParseAddress(args[0])
+		if err != nil {
+			return err
+		}
+		value, err := cheat.ParseValue(args[1])
+		if err != nil {
+			return err
+		}
+		front.machine.Poke(addr, value)
+
+	case "peek":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: peek ADDR")
+		}
+		addr, err := cheat.ParseAddress(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%04x %02x\n", addr, front.machine.Peek(addr))
+
+	case "save":
+		if front.cheatPath == "" {
+			return fmt.Errorf("no cheat file, the ROM is not loaded")
+		}
+		if err := cheat.Save(front.cheatPath, front.cheats); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s\n", front.cheatPath)
+
+	case "search":
+		start, end := uint16(cpu.RomOffset), uint16(cpu.VRAMOffset-1)
+		if len(args) == 2 {
+			var err error
+			if start, err = cheat.ParseAddress(args[0]); err != nil {
+				return err
+			}
+			if end, err = cheat.ParseAddress(args[1]); err != nil {
+				return err
+			}
+		} else if len(args) != 0 {
+			return fmt.Errorf("usage: search [START END]")
+		}
+		if end < start {
+			return fmt.Errorf("search ends before it starts")
+		}
+		front.search = cheat.NewSearch(front.machine, start, end)
+		fmt.Printf("%d candidates\n", front.search.Count())
+
+	case "eq", "changed", "unchanged", "gt", "lt":
+		if front.search == nil {
+			return fmt.Errorf("no search, start one with search")
+		}
+
+		var value uint8
+		cmp := map[string]cheat.Comparison{
+			"eq": cheat.Exact, "changed": cheat.Changed, "unchanged": cheat.Unchanged,
+			"gt": cheat.Greater, "lt": cheat.Less,
+		}[cmd]
+		if cmp == cheat.Exact {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: eq VALUE")
+			}
+			var err error
+			if value, err = cheat.ParseValue(args[0]); err != nil {
+				return err
+			}
+		}
+
+		front.search.Filter(front.machine, cmp, value)
+		fmt.Printf("%d candidates\n", front.search.Count())
+		if front.search.Count() <= listedCandidates {
+			front.listCandidates()
+		}
+
+	case "list":
+		if front.search == nil {
+			return fmt.Errorf("no search, start one with search")
+		}
+		front.listCandidates()
+
+	default:
+		return fmt.Errorf("unknown command %q, type help for the commands", cmd)
+	}
+
+	return nil
+}
+
+// cheat numbered as the console takes it, ticked when enabled
+func printCheat(n int, c cheat.Cheat) {
+	state := " "
+	if c.Enabled {
+		state = "x"
+	}
+	c.Enabled = true
+	fmt.Printf("%2d [%s] %s\n", n, state, c)
+}
+
+func (front *Frontend) listCandidates() {
+	for _, c := range front.search.Candidates(front.machine, listedCandidates) {
+		fmt.Printf("%04x %02x (was %02x)\n", c.Address, c.Value, c.Previous)
+	}
+	if front.search.Count() > listedCandidates {
+		fmt.Printf("... %d more\n", front.search.Count()-listedCandidates)
+	}
+}
+
+// cheat of the add and freeze commands
+func parseConsoleCheat(cmd string, args []string) (cheat.Cheat, error) {
+	usage := fmt.Errorf("usage: add ADDR VALUE COND [DESC]")
+	if cmd == "freeze" {
+		usage = fmt.Errorf("usage: freeze ADDR VALUE [DESC]")
+		if len(args) >= 2 {
+			args = append([]string{args[0], args[1], "always"}, args[2:]...)
+		}
+	}
+	if len(args) < 3 {
+		return cheat.Cheat{}, usage
+	}
+
+	return cheat.ParseCheat(strings.Join(args, " "))
+}
@@ -0,0 +1,62 @@
+package frontend
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+func TestConsoleInMovie(t *testing.T) {
+	tests := []struct {
+		command string
+		err     string
+	}{
+		{"poke 2000 12", "poke is not allowed while input is recorded or played back"},
+		{"add 2000 12 always", "add is not allowed while input is recorded or played back"},
+		{"freeze 2000 12", "freeze is not allowed while input is recorded or played back"},
+		{"peek 2000", ""},
+		{"search", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			var file bytes.Buffer
+			front := newTestFrontend(t, invaders.DefaultConfig, nil)
+			if err := front.RecordInput(&file); err != nil {
+				t.Fatal(err)
+			}
+
+			err := front.consoleCommand(strings.Fields(test.command))
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.err != "" && (err == nil || err.Error() != test.err):
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+			if got := front.machine.Peek(0x2000); got != 0 {
+				t.Errorf("poked %02x", got)
+			}
+			if len(front.cheats) != 0 {
+				t.Errorf("%d cheats added", len(front.cheats))
+			}
+		})
+	}
+}
+
+func TestConsoleOutsideMovie(t *testing.T) {
+	front := newTestFrontend(t, invaders.DefaultConfig, nil)
+
+	for _, command := range []string{"poke 2000 12", "add 2001 34 always", "freeze 2002 56 Lives"} {
+		if err := front.consoleCommand(strings.Fields(command)); err != nil {
+			t.Errorf("%s: %v", command, err)
+		}
+	}
+	if got := front.machine.Peek(0x2000); got != 0x12 {
+		t.Errorf("poked %02x, want 12", got)
+	}
+	if len(front.cheats) != 2 {
+		t.Errorf("%d cheats, want 2", len(front.cheats))
+	}
+}
@@ -0,0 +1,165 @@
+package frontend
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// left stick position past which it presses left or right
+const stickDeadZone = 8000
+
+// d-pad button followed by the stick pushed left or right
+var stickButtons = map[int]sdl.GameControllerButton{
+	-1: sdl.CONTROLLER_BUTTON_DPAD_LEFT,
+	1:  sdl.CONTROLLER_BUTTON_DPAD_RIGHT,
+}
+
+// game controller, assigned to player 1 or 2 in the order they are plugged
+// in, or to no one while both are taken
+type controller struct {
+	pad    *sdl.GameController
+	player int // -1 when unassigned
+	stick  int // -1 left, 0 centred, 1 right
+}
+
+// open a controller plugged in, SDL reports those present at start up too
+func (front *Frontend) addController(index int) {
+	if !sdl.IsGameController(index) {
+		return
+	}
+
+	pad := sdl.GameControllerOpen(index)
+	if pad == nil {
+		fmt.Printf("Controller %d failed: %s\n", index, sdl.GetError())
+		return
+	}
+
+	id := pad.Joystick().InstanceID()
+	if _, ok := front.controllers[id]; ok {
+		pad.Close()
+		return
+	}
+
+	c := &controller{pad: pad, player: front.freePlayer()}
+	front.controllers[id] = c
+	front.announceController(c)
+}
+
+// close an unplugged controller, its player goes to a waiting controller
+func (front *Frontend) removeController(id sdl.JoystickID) {
+	c, ok := front.controllers[id]
+	if !ok {
+		return
+	}
+
+	// the name goes with the controller
+	name := c.pad.Name()
+	front.releaseController(c)
+	c.pad.Close()
+	delete(front.controllers, id)
+	fmt.Printf("%s disconnected\n", name)
+
+	if c.player < 0 {
+		return
+	}
+	for _, waiting := range front.controllers {
+		if waiting.player < 0 {
+			waiting.player = c.player
+			front.announceController(waiting)
+			return
+		}
+	}
+}
+
+// swap the controllers of player 1 and player 2
+func (front *Frontend) swapControllers() {
+	for _, c := range front.controllers {
+		if c.player < 0 {
+			continue
+		}
+		front.releaseController(c)
+		c.player = 1 - c.player
+		front.announceController(c)
+	}
+}
+
+func (front *Frontend) closeControllers() {
+	for id, c := range front.controllers {
+		c.pad.Close()
+		delete(front.controllers, id)
+	}
+}
+
+// first player without a controller, -1 if both have one
+func (front *Frontend) freePlayer() int {
+	var taken [2]bool
+	for _, c := range front.controllers {
+		if c.player >= 0 {
+			taken[c.player] = true
+		}
+	}
+	for player, t := range taken {
+		if !t {
+			return player
+		}
+	}
+	return -1
+}
+
+func (front *Frontend) announceController(c *controller) {
+	if c.player < 0 {
+		fmt.Printf("%s connected, both players have a controller\n", c.pad.Name())
+		return
+	}
+	fmt.Printf("%s is player %d\n", c.pad.Name(), c.player+1)
+}
+
+// release the controls held on the controller
+func (front *Frontend) releaseController(c *controller) {
+	if c.player < 0 {
+		return
+	}
+	for _, input := range front.keymap.Pads[c.player] {
+		front.setInput(input, false)
+	}
+	c.stick = 0
+}
+
+// press or release the control bound to a button of the controller
+func (front *Frontend) controllerButton(id sdl.JoystickID, button sdl.GameControllerButton, pressed bool) {
+	c, ok := front.controllers[id]
+	if !ok || c.player < 0 {
+		return
+	}
+	if input, ok := front.keymap.Pads[c.player][button]; ok {
+		front.setInput(input, pressed)
+	}
+}
+
+// the left stick presses the controls bound to the d-pad
+func (front *Frontend) controllerStick(id sdl.JoystickID, value int16) {
+	c, ok := front.controllers[id]
+	if !ok || c.player < 0 {
+		return
+	}
+
+	stick := 0
+	switch {
+	case value < -stickDeadZone:
+		stick = -1
+	case value > stickDeadZone:
+		stick = 1
+	}
+	if stick == c.stick {
+		return
+	}
+
+	if c.stick != 0 {
+		front.controllerButton(id, stickButtons[c.stick], false)
+	}
+	if stick != 0 {
+		front.controllerButton(id, stickButtons[stick], true)
+	}
+	c.stick = stick
+}
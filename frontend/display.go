@@ -0,0 +1,106 @@
+package frontend
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ScaleMode is how the screen is fitted to the window
+type ScaleMode int
+
+// Scale modes
+const (
+	ScaleAspect  ScaleMode = iota // as large as fits, keeping the aspect ratio
+	ScaleInteger                  // largest integer multiple, letterboxed
+	ScaleFill                     // stretched over the whole window
+)
+
+var scaleModeNames = [...]string{"aspect", "integer", "fill"}
+
+func (mode ScaleMode) String() string {
+	return scaleModeNames[mode]
+}
+
+// ParseScaleMode from its name
+func ParseScaleMode(name string) (ScaleMode, error) {
+	for mode, modeName := range scaleModeNames {
+		if modeName == name {
+			return ScaleMode(mode), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown scale mode %q, expected aspect, integer or fill", name)
+}
+
+// Display settings of the window
+type Display struct {
+	Scale      ScaleMode
+	Linear     bool // linear filtering when scaling instead of nearest
+	Fullscreen bool
+	VSync      bool // present in step with the monitor refresh
+}
+
+// SetDisplay sets the initial display settings, it must be called before Run
+func (front *Frontend) SetDisplay(display Display) {
+	front.display = display
+}
+
+// fit the screen to the window following the scale mode
+func (front *Frontend) applyScaling() {
+	w, h := front.logicalSize.X, front.logicalSize.Y
+
+	switch front.display.Scale {
+	case ScaleFill:
+		front.renderer.SetLogicalSize(0, 0)
+		ow, oh, err := front.renderer.GetOutputSize()
+		if err != nil {
+			return
+		}
+		front.renderer.SetScale(float32(ow)/float32(w), float32(oh)/float32(h))
+	default:
+		front.renderer.SetLogicalSize(int32(w), int32(h))
+		front.renderer.SetIntegerScale(front.display.Scale == ScaleInteger)
+	}
+}
+
+// texture filtering hint, read when a texture is created
+func (front *Frontend) applyFiltering() {
+	quality := "nearest"
+	if front.display.Linear {
+		quality = "linear"
+	}
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, quality)
+}
+
+// switch to the next scale mode
+func (front *Frontend) cycleScaleMode() {
+	front.display.Scale = (front.display.Scale + 1) % ScaleMode(len(scaleModeNames))
+	front.applyScaling()
+}
+
+// switch between nearest and linear filtering, the textures are recreated
+// to pick it up
+func (front *Frontend) toggleFiltering() {
+	front.display.Linear = !front.display.Linear
+	front.applyFiltering()
+
+	if err := front.resizeTexture(front.textureSize); err != nil {
+		panic(err)
+	}
+	if front.artwork != nil {
+		front.artwork.destroy()
+		if err := front.artwork.load(front.renderer); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (front *Frontend) toggleFullscreen() {
+	front.display.Fullscreen = !front.display.Fullscreen
+
+	var flags uint32
+	if front.display.Fullscreen {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+	front.window.SetFullscreen(flags)
+}
@@ -0,0 +1,79 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/protoshark/invaders8080/capture"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// DumpVideo writes every emulated frame to w as an uncompressed Y4M stream,
+// with the overlay, rotation and filters applied
+func (front *Frontend) DumpVideo(w io.Writer) {
+	config := front.machine.Config()
+	front.videoDump = capture.NewY4MWriter(w, config.CPUClock, config.CyclesPerFrame())
+}
+
+// DumpAudio writes the sound of every emulated frame to ws as a WAV file,
+// samples are generated from the emulated cycles so they stay in sync with
+// the video dump
+func (front *Frontend) DumpAudio(ws io.WriteSeeker) error {
+	wav, err := capture.NewWAVWriter(ws, invaders.SampleRate)
+	if err != nil {
+		return err
+	}
+	front.audioDump = wav
+	return nil
+}
+
+// Dumping reports whether frames are being dumped
+func (front *Frontend) Dumping() bool {
+	return front.videoDump != nil || front.audioDump != nil
+}
+
+// dump the frame
+func (front *Frontend) dumpVideo(frame *image.RGBA) {
+	if front.videoDump != nil {
+		if err := front.videoDump.WriteFrame(frame); err != nil {
+			fmt.Printf("Video dump failed: %s\n", err)
+			front.videoDump = nil
+		}
+	}
+}
+
+// dump the samples generated while the frame ran
+func (front *Frontend) dumpAudio(samples []int16) {
+	if front.audioDump != nil {
+		if err := front.audioDump.WriteSamples(samples); err != nil {
+			fmt.Printf("Audio dump failed: %s\n", err)
+			front.audioDump = nil
+		}
+	}
+}
+
+// flush the dumps, the input recording and the GIF being recorded
+func (front *Frontend) closeDumps() {
+	if front.Recording() {
+		front.recordHotkey()
+	}
+	if front.videoDump != nil {
+		if err := front.videoDump.Flush(); err != nil {
+			fmt.Printf("Video dump failed: %s\n", err)
+		}
+		front.videoDump = nil
+	}
+	if front.audioDump != nil {
+		if err := front.audioDump.Close(); err != nil {
+			fmt.Printf("Audio dump failed: %s\n", err)
+		}
+		front.audioDump = nil
+	}
+	if front.inputRecord != nil {
+		if err := front.inputRecord.Flush(); err != nil {
+			fmt.Printf("Input recording failed: %s\n", err)
+		}
+		front.inputRecord = nil
+	}
+}
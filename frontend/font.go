@@ -0,0 +1,57 @@
+package frontend
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Glyphs of the built-in 3x5 font, rows from the top, lower case is drawn
+// upper case and unknown characters are blank
+var fontGlyphs = map[rune]string{
+	'0': "###|#.#|#.#|#.#|###", '1': ".#.|##.|.#.|.#.|###", '2': "###|..#|###|#..|###",
+	'3': "###|..#|.##|..#|###", '4': "#.#|#.#|###|..#|..#", '5': "###|#..|###|..#|###",
+	'6': "###|#..|###|#.#|###", '7': "###|..#|..#|.#.|.#.", '8': "###|#.#|###|#.#|###",
+	'9': "###|#.#|###|..#|###",
+
+	'A': ".#.|#.#|###|#.#|#.#", 'B': "##.|#.#|##.|#.#|##.", 'C': ".##|#..|#..|#..|.##",
+	'D': "##.|#.#|#.#|#.#|##.", 'E': "###|#..|##.|#..|###", 'F': "###|#..|##.|#..|#..",
+	'G': ".##|#..|#.#|#.#|.##", 'H': "#.#|#.#|###|#.#|#.#", 'I': "###|.#.|.#.|.#.|###",
+	'J': "..#|..#|..#|#.#|.#.", 'K': "#.#|#.#|##.|#.#|#.#", 'L': "#..|#..|#..|#..|###",
+	'M': "#.#|###|###|#.#|#.#", 'N': "##.|#.#|#.#|#.#|#.#", 'O': ".#.|#.#|#.#|#.#|.#.",
+	'P': "##.|#.#|##.|#..|#..", 'Q': ".#.|#.#|#.#|##.|.##", 'R': "##.|#.#|##.|#.#|#.#",
+	'S': ".##|#..|.#.|..#|##.", 'T': "###|.#.|.#.|.#.|.#.", 'U': "#.#|#.#|#.#|#.#|###",
+	'V': "#.#|#.#|#.#|#.#|.#.", 'W': "#.#|#.#|###|###|#.#", 'X': "#.#|#.#|.#.|#.#|#.#",
+	'Y': "#.#|#.#|.#.|.#.|.#.", 'Z': "###|..#|.#.|#..|###",
+
+	':': "...|.#.|...|.#.|...", '.': "...|...|...|...|.#.", ',': "...|...|...|.#.|#..", '%': "#.#|..#|.#.|#..|#.#",
+	'-': "...|...|###|...|...", '/': "..#|..#|.#.|#..|#..", '=': "...|###|...|###|...",
+	'(': "..#|.#.|.#.|.#.|..#", ')': "#..|.#.|.#.|.#.|#..",
+}
+
+// Font metrics, a character cell includes a pixel of spacing
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	cellWidth   = glyphWidth + 1
+	cellHeight  = glyphHeight + 1
+)
+
+// draw text with its top left corner at pt
+func drawText(dst *image.RGBA, pt image.Point, text string, c color.RGBA) {
+	for i, r := range []rune(strings.ToUpper(text)) {
+		glyph, ok := fontGlyphs[r]
+		if !ok {
+			continue
+		}
+
+		x0 := pt.X + i*cellWidth
+		for row, bits := range strings.Split(glyph, "|") {
+			for col, bit := range bits {
+				if bit == '#' {
+					dst.SetRGBA(x0+col, pt.Y+row, c)
+				}
+			}
+		}
+	}
+}
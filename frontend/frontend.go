@@ -0,0 +1,430 @@
+package frontend
+
+import (
+	"bufio"
+	"image"
+	"os"
+
+	"github.com/protoshark/invaders8080/capture"
+	"github.com/protoshark/invaders8080/cheat"
+	"github.com/protoshark/invaders8080/filter"
+	"github.com/protoshark/invaders8080/invaders"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Frontend plays a machine in an SDL window, with the keyboard and game
+// controllers
+type Frontend struct {
+	machine *invaders.Machine
+
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	texture  *sdl.Texture
+
+	// controls held on the keyboard and controllers
+	controls invaders.Controls
+
+	// monitor orientation, rotated holds the turned frame
+	rotation Rotation
+	rotated  *image.RGBA
+
+	// cabinet artwork, nil shows the bare screen
+	artwork *artwork
+
+	// post-processing of the frame before it is shown
+	filters     filter.Chain
+	textureSize image.Point
+
+	// frame shown last and the GIF being recorded
+	lastFrame  *image.RGBA
+	recorder   *capture.GIFRecorder
+	recordFile *os.File
+	captureDir string
+
+	// dumps of every emulated frame for offline encoding
+	videoDump *capture.Y4MWriter
+	audioDump *capture.WAVWriter
+
+	// input recording and playback, and the reset asked for the next frame
+	inputRecord   *bufio.Writer
+	inputPlayback *bufio.Reader
+	resetPending  bool
+
+	// fire buttons held under autofire and the frames they have been held,
+	// and the fast-forward key
+	autofireRate int
+	autofireHeld map[invaders.Input]int
+	fastForward  bool
+
+	// frame pacing, speed and pause
+	scheduler scheduler
+
+	// key and button bindings of the cabinet controls, and the controllers
+	// plugged in
+	keymap      Keymap
+	controllers map[sdl.JoystickID]*controller
+
+	// window scaling, logicalSize is the screen or the artwork layout
+	display     Display
+	logicalSize image.Point
+
+	// debug overlay and sprite hitboxes
+	hud hud
+
+	// memory viewer window, nil while closed
+	viewer *memoryViewer
+
+	// cheats applied before every frame and the file they are saved to, the
+	// RAM search and the commands typed on the console
+	cheats    []cheat.Cheat
+	cheatPath string
+	search    *cheat.Search
+	console   chan string
+
+	// hi-score kept across sessions, nil when not
+	hiScores *hiScores
+}
+
+// New frontend for the machine
+func New(machine *invaders.Machine) *Frontend {
+	return &Frontend{
+		machine:      machine,
+		keymap:       DefaultKeymap,
+		controllers:  make(map[sdl.JoystickID]*controller),
+		autofireHeld: make(map[invaders.Input]int),
+		scheduler:    newScheduler(machine.Config().FrameRate()),
+	}
+}
+
+// press or release a cabinet control
+func (front *Frontend) setInput(input invaders.Input, pressed bool) {
+	front.controls.Set(input, pressed)
+}
+
+// Run the machine in a window until it is closed, the ROM must be loaded
+// first
+func (front *Frontend) Run() {
+	front.setup()
+	defer sdl.Quit()
+	defer front.renderer.Destroy()
+	// the texture is recreated when the filters change the frame size
+	defer func() { front.texture.Destroy() }()
+	if front.artwork != nil {
+		defer front.artwork.destroy()
+	}
+	defer front.hud.destroy()
+	defer front.closeMemoryViewer()
+	defer front.closeDumps()
+	defer front.closeControllers()
+	defer front.saveHiScores()
+	front.startHiScores()
+
+	running := true
+	for running {
+		// fast-forward runs uncapped
+		if !front.fastForward {
+			front.scheduler.wait()
+		}
+		front.runConsole()
+
+		// keep the window alive while paused
+		if !front.scheduler.running() {
+			running = front.handleEvents()
+			front.present(front.lastFrame)
+			continue
+		}
+
+		// the machine waits for the initials of a best score
+		if front.enteringInitials() {
+			running = front.handleEvents()
+			front.stepInitials()
+			front.present(front.renderFrame(front.initialsFrame()))
+			continue
+		}
+
+		playing := front.Playing()
+		controls := front.frameControls()
+		// cheats would break the replay of the inputs
+		if !front.inMovie() {
+			cheat.Apply(front.machine, front.cheats)
+		}
+		frame, audio := front.machine.StepFrame(controls)
+		if front.viewer != nil {
+			front.viewer.update(front.machine)
+		}
+		running = front.handleEvents()
+
+		// fast-forward skips presenting, and rendering unless the frame is
+		// recorded
+		present := !front.fastForward
+		if present || front.videoDump != nil || front.recorder != nil {
+			shown := front.renderFrame(frame)
+			front.dumpVideo(shown)
+			if present {
+				front.present(shown)
+			}
+		}
+		front.dumpAudio(audio)
+
+		// a dump of a played back session ends with it
+		if playing && !front.Playing() && front.Dumping() {
+			running = false
+		}
+	}
+}
+
+func (front *Frontend) setup() {
+	if err := sdl.Init(sdl.INIT_VIDEO | sdl.INIT_GAMECONTROLLER); err != nil {
+		panic(err)
+	}
+
+	// the window shows the whole layout with artwork, drawn at its own size
+	screen := front.outputSize()
+	width, height, scale := int32(screen.X), int32(screen.Y), int32(2)
+	if front.artwork != nil {
+		width, height, scale = int32(front.artwork.layout.Size.X), int32(front.artwork.layout.Size.Y), 1
+	}
+	front.logicalSize = image.Pt(int(width), int(height))
+
+	flags := uint32(sdl.WINDOW_RESIZABLE)
+	if front.display.Fullscreen {
+		flags |= sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	// create the window
+	window, err := sdl.CreateWindow(front.machine.Profile().Description, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		width*scale, height*scale, flags)
+
+	if err != nil {
+		sdl.Quit()
+		panic(err)
+	}
+	front.window = window
+
+	// set minimum size
+	window.SetMinimumSize(int32(screen.X), int32(screen.Y))
+
+	// hide cursor
+	sdl.ShowCursor(sdl.DISABLE)
+
+	rendererFlags := uint32(sdl.RENDERER_ACCELERATED)
+	if front.display.VSync {
+		rendererFlags |= sdl.RENDERER_PRESENTVSYNC
+	}
+	front.scheduler.vsync = front.display.VSync
+	front.updateDisplayRate()
+
+	front.renderer, err = sdl.CreateRenderer(window, -1, rendererFlags)
+	if err != nil {
+		window.Destroy()
+		sdl.Quit()
+		panic(err)
+	}
+
+	front.applyScaling()
+	front.applyFiltering()
+
+	if err = front.resizeTexture(screen); err != nil {
+		front.renderer.Destroy()
+		window.Destroy()
+		sdl.Quit()
+		panic(err)
+	}
+
+	if front.artwork != nil {
+		if err := front.artwork.load(front.renderer); err != nil {
+			front.artwork.destroy()
+			front.texture.Destroy()
+			front.renderer.Destroy()
+			window.Destroy()
+			sdl.Quit()
+			panic(err)
+		}
+	}
+
+	front.present(front.renderFrame(front.machine.Frame()))
+}
+
+// SetFilters sets the post-processing applied to every frame
+func (front *Frontend) SetFilters(filters filter.Chain) {
+	front.filters = filters
+}
+
+// the frame as shown, with the rotation and filters applied
+func (front *Frontend) renderFrame(frame *image.RGBA) *image.RGBA {
+	if front.hud.hitboxes {
+		frame = front.drawHitboxes(frame)
+	}
+	frame = front.filters.Apply(front.rotatedFrame(frame))
+
+	front.lastFrame = frame
+	front.recordFrame(frame)
+
+	return frame
+}
+
+func (front *Frontend) present(frame *image.RGBA) {
+	// filters may change the frame size
+	if size := frame.Rect.Size(); size != front.textureSize {
+		if err := front.resizeTexture(size); err != nil {
+			panic(err)
+		}
+	}
+
+	front.texture.Update(nil, frame.Pix, frame.Stride)
+	front.renderer.Clear()
+
+	if art := front.artwork; art != nil {
+		if art.background != nil {
+			front.renderer.Copy(art.background, nil, sdlRect(art.layout.BackgroundRect))
+		}
+		front.renderer.Copy(front.texture, nil, sdlRect(art.layout.Screen))
+		if art.bezel != nil {
+			front.renderer.Copy(art.bezel, nil, sdlRect(art.layout.BezelRect))
+		}
+	} else {
+		front.renderer.Copy(front.texture, nil, nil)
+	}
+	if front.hud.enabled {
+		front.drawHUD()
+	}
+
+	front.hud.tick()
+	front.renderer.Present()
+
+	if front.viewer != nil {
+		front.viewer.present(front.machine)
+	}
+}
+
+// (re)create the screen texture for frames of the given size
+func (front *Frontend) resizeTexture(size image.Point) error {
+	if front.texture != nil {
+		front.texture.Destroy()
+	}
+
+	texture, err := front.renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STREAMING,
+		int32(size.X), int32(size.Y))
+	if err != nil {
+		front.texture = nil
+		return err
+	}
+
+	// the screen is seen through a half-silvered mirror over the backdrop
+	if front.artwork != nil && front.artwork.layout.Background != "" {
+		texture.SetBlendMode(sdl.BLENDMODE_ADD)
+	}
+
+	front.texture, front.textureSize = texture, size
+	return nil
+}
+
+func (front *Frontend) handleEvents() bool {
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		switch e := event.(type) {
+
+		case *sdl.QuitEvent:
+			return false
+
+		case *sdl.WindowEvent:
+			if front.viewer != nil && front.viewer.owns(e.WindowID) {
+				if e.Event == sdl.WINDOWEVENT_CLOSE {
+					front.closeMemoryViewer()
+				}
+				continue
+			}
+
+			// closing the game window quits, even with the viewer open
+			if e.Event == sdl.WINDOWEVENT_CLOSE {
+				return false
+			}
+			// fill scaling follows the window size
+			if e.Event == sdl.WINDOWEVENT_SIZE_CHANGED {
+				front.applyScaling()
+			}
+			// the window may have moved to another monitor
+			if e.Event == sdl.WINDOWEVENT_MOVED {
+				front.updateDisplayRate()
+			}
+
+		case *sdl.ControllerDeviceEvent:
+			switch e.Type {
+			case sdl.CONTROLLERDEVICEADDED:
+				front.addController(int(e.Which))
+			case sdl.CONTROLLERDEVICEREMOVED:
+				front.removeController(e.Which)
+			}
+
+		case *sdl.ControllerButtonEvent:
+			front.controllerButton(e.Which, sdl.GameControllerButton(e.Button), e.State == sdl.PRESSED)
+
+		case *sdl.ControllerAxisEvent:
+			if e.Axis == sdl.CONTROLLER_AXIS_LEFTX {
+				front.controllerStick(e.Which, e.Value)
+			}
+
+		case *sdl.KeyboardEvent:
+			key := e.Keysym.Scancode
+			pressed := e.Type == sdl.KEYDOWN
+
+			if input, ok := front.keymap.Keys[key]; ok {
+				front.setInput(input, pressed)
+				continue
+			}
+			if key == sdl.SCANCODE_TAB { // FAST-FORWARD
+				front.fastForward = pressed
+				continue
+			}
+			if !pressed || e.Repeat != 0 {
+				continue
+			}
+
+			switch key {
+			case sdl.SCANCODE_ESCAPE:
+				return false
+
+			case sdl.SCANCODE_F3: // RESET
+				front.resetPending = true
+
+			case sdl.SCANCODE_P: // PAUSE
+				front.togglePause()
+			case sdl.SCANCODE_N: // FRAME ADVANCE
+				front.advanceFrame()
+			case sdl.SCANCODE_MINUS: // SLOWER
+				front.changeSpeed(-1)
+			case sdl.SCANCODE_EQUALS: // FASTER
+				front.changeSpeed(1)
+
+			case sdl.SCANCODE_F4: // DEBUG HUD
+				front.toggleHUD()
+			case sdl.SCANCODE_F6: // HITBOXES
+				front.toggleHitboxes()
+			case sdl.SCANCODE_F5: // MEMORY VIEWER
+				front.toggleMemoryViewer()
+			case sdl.SCANCODE_PAGEUP:
+				front.scrollMemory(-1)
+			case sdl.SCANCODE_PAGEDOWN:
+				front.scrollMemory(1)
+			case sdl.SCANCODE_HOME:
+				front.homeMemory()
+
+			case sdl.SCANCODE_F7: // SWAP CONTROLLERS
+				front.swapControllers()
+
+			case sdl.SCANCODE_F9: // SCALE MODE
+				front.cycleScaleMode()
+			case sdl.SCANCODE_F10: // NEAREST/LINEAR FILTERING
+				front.toggleFiltering()
+			case sdl.SCANCODE_F11: // FULLSCREEN
+				front.toggleFullscreen()
+
+			case sdl.SCANCODE_F12: // SCREENSHOT
+				front.screenshotHotkey()
+			case sdl.SCANCODE_F8: // GIF RECORDING
+				front.recordHotkey()
+			}
+		}
+	}
+
+	return true
+}
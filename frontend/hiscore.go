@@ -0,0 +1,229 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/protoshark/invaders8080/hiscore"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// hi-score kept across sessions, and the initials of the best scores
+type hiScores struct {
+	table *hiscore.Table
+	path  string
+
+	// table of the best scores with initials, the scores waiting for them
+	// and the one being entered
+	initials bool
+	pending  []int
+	entry    *initialsEntry
+
+	frame *image.RGBA
+}
+
+// initials entered with the controls, then the table shown
+type initialsEntry struct {
+	score   int
+	letters []byte
+	pos     int
+
+	// the table is shown for some frames once entered
+	showing bool
+	rank    int
+	frames  int
+
+	previous invaders.Controls
+}
+
+const (
+	initialsLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	tableFrames     = 600
+)
+
+// Overlay colours
+var (
+	overlayText   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	overlayCursor = color.RGBA{0xff, 0xff, 0x00, 0xff}
+)
+
+// SetHiScores keeps the hi-score of the table across sessions, saving it to
+// path at the end of every game, or never with an empty path. With initials
+// the best scores are entered into the table with the controls
+func (front *Frontend) SetHiScores(table *hiscore.Table, path string, initials bool) {
+	front.hiScores = &hiScores{table: table, path: path, initials: initials}
+}
+
+// hand the hi-score to the machine and follow the games, unless the inputs
+// are recorded or played back as the hi-score written into RAM would break
+// their replay. The table is then dropped for the session, so it is not
+// saved either
+func (front *Frontend) startHiScores() {
+	h := front.hiScores
+	if h == nil {
+		return
+	}
+	if front.inMovie() {
+		front.hiScores = nil
+		return
+	}
+
+	front.machine.SetHiScore(h.table.HiScore)
+	front.machine.OnGameOver(front.gameOver)
+}
+
+func (front *Frontend) saveHiScores() {
+	if h := front.hiScores; h != nil && h.path != "" {
+		if err := h.table.Save(h.path); err != nil {
+			fmt.Printf("Hi-score save failed: %s\n", err)
+		}
+	}
+}
+
+// keep the hi-score of a game ending, queueing its scores for initials
+func (front *Frontend) gameOver(scores [2]int, hiScore int) {
+	h := front.hiScores
+	if hiScore > h.table.HiScore {
+		h.table.HiScore = hiScore
+	}
+
+	if h.initials {
+		for _, score := range scores {
+			if h.table.Qualifies(score) {
+				h.pending = append(h.pending, score)
+			}
+		}
+	}
+	front.saveHiScores()
+}
+
+// whether initials are being entered, the machine waits meanwhile
+func (front *Frontend) enteringInitials() bool {
+	h := front.hiScores
+	if h == nil {
+		return false
+	}
+
+	if h.entry == nil && len(h.pending) > 0 {
+		h.entry = &initialsEntry{score: h.pending[0], letters: []byte("AAA"), previous: front.controls}
+		h.pending = h.pending[1:]
+	}
+	return h.entry != nil
+}
+
+// left and right choose a letter, fire enters it
+func (front *Frontend) stepInitials() {
+	h := front.hiScores
+	e := h.entry
+
+	pressed := func(inputs ...invaders.Input) bool {
+		for _, input := range inputs {
+			if front.controls.Pressed(input) && !e.previous.Pressed(input) {
+				return true
+			}
+		}
+		return false
+	}
+	fire := pressed(invaders.P1Fire, invaders.P2Fire)
+	left := pressed(invaders.P1Left, invaders.P2Left)
+	right := pressed(invaders.P1Right, invaders.P2Right)
+	e.previous = front.controls
+
+	if e.showing {
+		e.frames--
+		if e.frames <= 0 || fire {
+			h.entry = nil
+		}
+		return
+	}
+
+	letter := func(step int) {
+		n := len(initialsLetters)
+		i := (int(e.letters[e.pos]-'A') + step + n) % n
+		e.letters[e.pos] = initialsLetters[i]
+	}
+	switch {
+	case left:
+		letter(-1)
+	case right:
+		letter(1)
+	case fire:
+		e.pos++
+		if e.pos == hiscore.InitialsLength {
+			e.rank = h.table.Add(string(e.letters), e.score)
+			e.showing, e.frames = true, tableFrames
+			front.saveHiScores()
+		}
+	}
+}
+
+// the frame with the initials entry or the table over it
+func (front *Frontend) initialsFrame() *image.RGBA {
+	h := front.hiScores
+	e := h.entry
+
+	frame := front.machine.Frame()
+	if h.frame == nil || h.frame.Rect != frame.Rect {
+		h.frame = image.NewRGBA(frame.Rect)
+	}
+	copy(h.frame.Pix, frame.Pix)
+
+	var lines []string
+	if e.showing {
+		lines = append(lines, "BEST SCORES", "")
+		for i, entry := range h.table.Entries {
+			lines = append(lines, fmt.Sprintf("%2d  %s  %04d", i+1, entry.Initials, entry.Score))
+		}
+	} else {
+		lines = []string{
+			fmt.Sprintf("SCORE %04d", e.score),
+			"",
+			"ENTER YOUR INITIALS",
+			"",
+			"",
+			"",
+			"LEFT/RIGHT TO CHOOSE",
+			"FIRE TO ENTER",
+		}
+	}
+
+	top := (frame.Rect.Dy() - len(lines)*cellHeight) / 2
+	dim(h.frame, image.Rect(0, top-cellHeight, frame.Rect.Dx(), top+(len(lines)+1)*cellHeight))
+	for i, line := range lines {
+		drawText(h.frame, image.Pt(centered(frame, line), top+i*cellHeight), line, overlayText)
+	}
+
+	if !e.showing {
+		// the letters spaced out, the one chosen highlighted
+		y := top + 4*cellHeight
+		x := (frame.Rect.Dx() - (2*hiscore.InitialsLength-1)*cellWidth) / 2
+		for i, letter := range e.letters {
+			c := overlayText
+			if i == e.pos {
+				c = overlayCursor
+			}
+			if i <= e.pos {
+				drawText(h.frame, image.Pt(x+2*i*cellWidth, y), string(letter), c)
+			}
+		}
+	}
+
+	return h.frame
+}
+
+// x of a line centered on the frame
+func centered(frame *image.RGBA, line string) int {
+	return (frame.Rect.Dx() - len(line)*cellWidth) / 2
+}
+
+// darken the area so the text over it stands out
+func dim(img *image.RGBA, r image.Rectangle) {
+	r = r.Intersect(img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			row[i], row[i+1], row[i+2] = row[i]/4, row[i+1]/4, row[i+2]/4
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package frontend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/protoshark/invaders8080/hiscore"
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+func TestHiScoresSaved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hiscores")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name  string
+		path  string
+		movie bool
+		saved bool
+	}{
+		{"saved", "saved.json", false, true},
+		{"recording input", "recording.json", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.path)
+			front := newTestFrontend(t, invaders.DefaultConfig, nil)
+			front.SetHiScores(&hiscore.Table{HiScore: 1230}, path, false)
+			if test.movie {
+				if err := front.RecordInput(&bytes.Buffer{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			front.startHiScores()
+			front.saveHiScores()
+
+			_, err := os.Stat(path)
+			if saved := err == nil; saved != test.saved {
+				t.Errorf("saved %v, want %v", saved, test.saved)
+			}
+		})
+	}
+}
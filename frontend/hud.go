@@ -0,0 +1,208 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/protoshark/invaders8080/gamestate"
+	"github.com/protoshark/invaders8080/invaders"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// debug overlay, the HUD is drawn over the window and the hitboxes into the
+// frame
+type hud struct {
+	enabled  bool
+	hitboxes bool
+
+	image   *image.RGBA
+	texture *sdl.Texture
+	outline *image.RGBA // frame with the hitboxes drawn
+
+	// frames presented during the last second
+	frames int
+	since  time.Time
+	fps    float64
+}
+
+// HUD colours
+var (
+	hudText       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	hudBackground = color.RGBA{0x00, 0x00, 0x00, 0xa0}
+
+	hitboxShip   = color.RGBA{0x00, 0xff, 0x00, 0xff}
+	hitboxAlien  = color.RGBA{0xff, 0x00, 0xff, 0xff}
+	hitboxShot   = color.RGBA{0xff, 0xff, 0x00, 0xff}
+	hitboxBomb   = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	hitboxSaucer = color.RGBA{0x00, 0xff, 0xff, 0xff}
+)
+
+// sprite sizes in pixels, along the lines then the columns of the upright
+// screen
+var (
+	shipSize   = image.Pt(16, 8)
+	alienSize  = image.Pt(16, 8)
+	alienStep  = image.Pt(16, 16)
+	shotSize   = image.Pt(1, 4)
+	bombSize   = image.Pt(3, 8)
+	saucerSize = image.Pt(24, 8)
+)
+
+func (front *Frontend) toggleHUD() {
+	front.hud.enabled = !front.hud.enabled
+}
+
+func (front *Frontend) toggleHitboxes() {
+	front.hud.hitboxes = !front.hud.hitboxes
+}
+
+// count a presented frame
+func (h *hud) tick() {
+	h.frames++
+	if elapsed := time.Since(h.since); elapsed >= time.Second {
+		h.fps = float64(h.frames) / elapsed.Seconds()
+		h.frames, h.since = 0, time.Now()
+	}
+}
+
+// lines of the HUD
+func (front *Frontend) hudLines() []string {
+	machine := front.machine
+	regs := machine.Registers()
+	state := gamestate.Decode(machine)
+
+	ei := "DI"
+	if regs.IntEnable {
+		ei = "EI"
+	}
+	mode := "DEMO"
+	if state.Playing {
+		mode = fmt.Sprintf("PLAYER %d", state.Player)
+	}
+	direction := "RIGHT"
+	if state.Rack.Left {
+		direction = "LEFT"
+	}
+
+	lines := []string{
+		fmt.Sprintf("FPS %.1f  SPEED %d%%  CYCLES %d", front.hud.fps, front.scheduler.speed,
+			machine.Config().CyclesPerFrame()),
+		fmt.Sprintf("PC %04X SP %04X %s", regs.PC, regs.SP, ei),
+		fmt.Sprintf("A %02X F %02X BC %02X%02X DE %02X%02X HL %02X%02X",
+			regs.A, regs.Flags, regs.B, regs.C, regs.D, regs.E, regs.H, regs.L),
+		fmt.Sprintf("%s  CREDITS %d  HI %04d", mode, state.Credits, state.HiScore),
+		fmt.Sprintf("P1 %04d SHIPS %d WAVE %d", state.Scores[0], state.Players[0].Ships, state.Players[0].Wave),
+		fmt.Sprintf("P2 %04d SHIPS %d WAVE %d", state.Scores[1], state.Players[1].Ships, state.Players[1].Wave),
+		fmt.Sprintf("RACK %d,%d %s  ALIENS %d", state.Rack.X, state.Rack.Y, direction, state.Rack.Count),
+		fmt.Sprintf("SHIP %d  SHOT %02X %d,%d", state.Ship.X, state.Shot.Status, state.Shot.X, state.Shot.Y),
+	}
+	for i, name := range []string{"ROLL", "PLUNG", "SQUIG"} {
+		bomb := state.Bombs[i]
+		lines = append(lines, fmt.Sprintf("%s %02X STEP %d %d,%d", name, bomb.Status, bomb.Steps, bomb.X, bomb.Y))
+	}
+	if state.Saucer.Active {
+		lines = append(lines, fmt.Sprintf("SAUCER %d", state.Saucer.X))
+	}
+
+	return lines
+}
+
+// draw the HUD over the top left of the window
+func (front *Frontend) drawHUD() {
+	h := &front.hud
+	lines := front.hudLines()
+
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	size := image.Pt(width*cellWidth+2, len(lines)*cellHeight+2)
+
+	if h.image == nil || h.image.Rect.Size() != size {
+		if h.texture != nil {
+			h.texture.Destroy()
+			h.texture = nil
+		}
+		h.image = image.NewRGBA(image.Rectangle{Max: size})
+
+		texture, err := front.renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STREAMING,
+			int32(size.X), int32(size.Y))
+		if err != nil {
+			fmt.Printf("HUD failed: %s\n", err)
+			h.enabled = false
+			return
+		}
+		texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+		h.texture = texture
+	}
+
+	for i := 0; i < len(h.image.Pix); i += 4 {
+		copy(h.image.Pix[i:i+4], []uint8{hudBackground.R, hudBackground.G, hudBackground.B, hudBackground.A})
+	}
+	for i, line := range lines {
+		drawText(h.image, image.Pt(1, 1+i*cellHeight), line, hudText)
+	}
+
+	h.texture.Update(nil, h.image.Pix, h.image.Stride)
+	front.renderer.Copy(h.texture, nil, sdlRect(h.image.Rect))
+}
+
+func (h *hud) destroy() {
+	if h.texture != nil {
+		h.texture.Destroy()
+		h.texture = nil
+	}
+}
+
+// a copy of the frame with the sprites outlined
+func (front *Frontend) drawHitboxes(frame *image.RGBA) *image.RGBA {
+	h := &front.hud
+	if h.outline == nil || h.outline.Rect != frame.Rect {
+		h.outline = image.NewRGBA(frame.Rect)
+	}
+	copy(h.outline.Pix, frame.Pix)
+
+	state := gamestate.Decode(front.machine)
+
+	if state.Ship.Alive {
+		outline(h.outline, state.Ship.X, state.Ship.Y, shipSize, hitboxShip)
+	}
+	for row, aliens := range state.Rack.Aliens {
+		for col, alive := range aliens {
+			if alive {
+				outline(h.outline, state.Rack.X+col*alienStep.X, state.Rack.Y+row*alienStep.Y, alienSize, hitboxAlien)
+			}
+		}
+	}
+	if state.Shot.Active {
+		outline(h.outline, state.Shot.X, state.Shot.Y, shotSize, hitboxShot)
+	}
+	for _, bomb := range state.Bombs {
+		if bomb.Active {
+			outline(h.outline, bomb.X, bomb.Y, bombSize, hitboxBomb)
+		}
+	}
+	if state.Saucer.Active {
+		outline(h.outline, state.Saucer.X, state.Saucer.Y, saucerSize, hitboxSaucer)
+	}
+
+	return h.outline
+}
+
+// outline a sprite at the game coordinates, x along the lines and y up the
+// columns from the bottom of the screen
+func outline(dst *image.RGBA, x, y int, size image.Point, c color.RGBA) {
+	r := image.Rect(x, int(invaders.ScreenHeight)-y-size.Y, x+size.X, int(invaders.ScreenHeight)-y)
+	for px := r.Min.X; px < r.Max.X; px++ {
+		dst.SetRGBA(px, r.Min.Y, c)
+		dst.SetRGBA(px, r.Max.Y-1, c)
+	}
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		dst.SetRGBA(r.Min.X, py, c)
+		dst.SetRGBA(r.Max.X-1, py, c)
+	}
+}
@@ -0,0 +1,196 @@
+package frontend
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/protoshark/invaders8080/invaders"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Keymap binds keys and controller buttons to cabinet controls, a control
+// may have several bindings
+type Keymap struct {
+	Keys map[sdl.Scancode]invaders.Input
+	// buttons of the controllers of player 1 and player 2, the left stick
+	// follows the d-pad
+	Pads [2]map[sdl.GameControllerButton]invaders.Input
+}
+
+// DefaultKeymap of the keyboard and controllers
+var DefaultKeymap = Keymap{
+	Keys: map[sdl.Scancode]invaders.Input{
+		sdl.SCANCODE_C:      invaders.Coin,
+		sdl.SCANCODE_S:      invaders.P1Start,
+		sdl.SCANCODE_RETURN: invaders.P2Start,
+		sdl.SCANCODE_W:      invaders.P1Fire,
+		sdl.SCANCODE_A:      invaders.P1Left,
+		sdl.SCANCODE_D:      invaders.P1Right,
+		sdl.SCANCODE_UP:     invaders.P2Fire,
+		sdl.SCANCODE_LEFT:   invaders.P2Left,
+		sdl.SCANCODE_RIGHT:  invaders.P2Right,
+		sdl.SCANCODE_T:      invaders.Tilt,
+		sdl.SCANCODE_F2:     invaders.Service,
+	},
+	Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+		{
+			sdl.CONTROLLER_BUTTON_A:          invaders.P1Fire,
+			sdl.CONTROLLER_BUTTON_DPAD_LEFT:  invaders.P1Left,
+			sdl.CONTROLLER_BUTTON_DPAD_RIGHT: invaders.P1Right,
+			sdl.CONTROLLER_BUTTON_START:      invaders.P1Start,
+			sdl.CONTROLLER_BUTTON_BACK:       invaders.Coin,
+		},
+		{
+			sdl.CONTROLLER_BUTTON_A:          invaders.P2Fire,
+			sdl.CONTROLLER_BUTTON_DPAD_LEFT:  invaders.P2Left,
+			sdl.CONTROLLER_BUTTON_DPAD_RIGHT: invaders.P2Right,
+			sdl.CONTROLLER_BUTTON_START:      invaders.P2Start,
+			sdl.CONTROLLER_BUTTON_BACK:       invaders.Coin,
+		},
+	},
+}
+
+// player owning a control, the buttons bound to the shared controls are
+// bound on both controllers
+var inputPlayers = map[invaders.Input]int{
+	invaders.P1Start: 0, invaders.P1Fire: 0, invaders.P1Left: 0, invaders.P1Right: 0,
+	invaders.P2Start: 1, invaders.P2Fire: 1, invaders.P2Left: 1, invaders.P2Right: 1,
+}
+
+// pads a controller button bound to the control applies to
+func padsOf(input invaders.Input) []int {
+	if player, ok := inputPlayers[input]; ok {
+		return []int{player}
+	}
+	return []int{0, 1}
+}
+
+// SetKeymap sets the key bindings
+func (front *Frontend) SetKeymap(keymap Keymap) {
+	front.keymap = keymap
+}
+
+// LoadKeymap reads key bindings from a file over the default ones
+func LoadKeymap(path string) (Keymap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Keymap{}, err
+	}
+	defer file.Close()
+
+	keymap, err := ParseKeymap(file, DefaultKeymap)
+	if err != nil {
+		return Keymap{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return keymap, nil
+}
+
+// ParseKeymap reads key bindings over base, one control per line as
+//
+//	control: key[, key...]
+//
+// with the control names of ParseInput, the SDL key names and the SDL
+// controller button names prefixed with pad:, e.g.
+//
+//	p1-fire: Space, Left Ctrl, pad:a
+//
+// buttons of player controls are bound on that player's controller, those
+// of shared controls on both. A control listed replaces all its bindings in
+// base, blank lines and lines starting with # are ignored. A key or button
+// bound to two controls is an error, whether both are listed or one keeps
+// its binding from base
+func ParseKeymap(r io.Reader, base Keymap) (Keymap, error) {
+	keymap := Keymap{Keys: make(map[sdl.Scancode]invaders.Input)}
+	for key, input := range base.Keys {
+		keymap.Keys[key] = input
+	}
+	for i, pad := range base.Pads {
+		keymap.Pads[i] = make(map[sdl.GameControllerButton]invaders.Input)
+		for button, input := range pad {
+			keymap.Pads[i][button] = input
+		}
+	}
+
+	// the controls listed lose their bindings first, so keys can be swapped
+	// between controls whatever the order of the lines
+	type binding struct {
+		line  int
+		input invaders.Input
+		name  string
+	}
+	var bindings []binding
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, ":", 2)
+		if len(fields) != 2 {
+			return Keymap{}, fmt.Errorf("line %d: expected control: keys", line)
+		}
+		input, err := invaders.ParseInput(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return Keymap{}, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		keymap.unbind(input)
+
+		for _, name := range strings.Split(fields[1], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				bindings = append(bindings, binding{line, input, name})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Keymap{}, err
+	}
+
+	for _, b := range bindings {
+		if strings.HasPrefix(b.name, "pad:") {
+			button := sdl.GameControllerGetButtonFromString(strings.TrimPrefix(b.name, "pad:"))
+			if button == sdl.CONTROLLER_BUTTON_INVALID {
+				return Keymap{}, fmt.Errorf("line %d: unknown controller button %q", b.line, b.name)
+			}
+			for _, pad := range padsOf(b.input) {
+				if bound, ok := keymap.Pads[pad][button]; ok && bound != b.input {
+					return Keymap{}, fmt.Errorf("line %d: %q is already bound to %s", b.line, b.name, bound)
+				}
+				keymap.Pads[pad][button] = b.input
+			}
+			continue
+		}
+
+		key := sdl.GetScancodeFromName(b.name)
+		if key == sdl.SCANCODE_UNKNOWN {
+			return Keymap{}, fmt.Errorf("line %d: unknown key %q", b.line, b.name)
+		}
+		if bound, ok := keymap.Keys[key]; ok && bound != b.input {
+			return Keymap{}, fmt.Errorf("line %d: %q is already bound to %s", b.line, b.name, bound)
+		}
+		keymap.Keys[key] = b.input
+	}
+
+	return keymap, nil
+}
+
+// remove every binding of the control
+func (keymap Keymap) unbind(input invaders.Input) {
+	for key, bound := range keymap.Keys {
+		if bound == input {
+			delete(keymap.Keys, key)
+		}
+	}
+	for _, pad := range keymap.Pads {
+		for button, bound := range pad {
+			if bound == input {
+				delete(pad, button)
+			}
+		}
+	}
+}
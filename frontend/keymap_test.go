@@ -0,0 +1,124 @@
+package frontend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/protoshark/invaders8080/invaders"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type (
+	keys    = map[sdl.Scancode]invaders.Input
+	buttons = map[sdl.GameControllerButton]invaders.Input
+)
+
+func testKeymap() Keymap {
+	return Keymap{
+		Keys: keys{sdl.SCANCODE_C: invaders.Coin, sdl.SCANCODE_X: invaders.P1Fire},
+		Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+			{sdl.CONTROLLER_BUTTON_A: invaders.P1Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+			{sdl.CONTROLLER_BUTTON_A: invaders.P2Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+		},
+	}
+}
+
+func TestParseKeymap(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		keymap Keymap
+		err    string
+	}{
+		{"empty", "# nothing\n\n", testKeymap(), ""},
+		{
+			"player control",
+			"p1-fire: Space, Left Ctrl, pad:x",
+			Keymap{
+				Keys: keys{sdl.SCANCODE_C: invaders.Coin, sdl.SCANCODE_SPACE: invaders.P1Fire, sdl.SCANCODE_LCTRL: invaders.P1Fire},
+				Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+					{sdl.CONTROLLER_BUTTON_X: invaders.P1Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+					{sdl.CONTROLLER_BUTTON_A: invaders.P2Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+				},
+			},
+			"",
+		},
+		{
+			"shared control",
+			"  coin :Return,pad:start  ",
+			Keymap{
+				Keys: keys{sdl.SCANCODE_RETURN: invaders.Coin, sdl.SCANCODE_X: invaders.P1Fire},
+				Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+					{sdl.CONTROLLER_BUTTON_A: invaders.P1Fire, sdl.CONTROLLER_BUTTON_START: invaders.Coin},
+					{sdl.CONTROLLER_BUTTON_A: invaders.P2Fire, sdl.CONTROLLER_BUTTON_START: invaders.Coin},
+				},
+			},
+			"",
+		},
+		{
+			"unbound",
+			"p2-fire:",
+			Keymap{
+				Keys: keys{sdl.SCANCODE_C: invaders.Coin, sdl.SCANCODE_X: invaders.P1Fire},
+				Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+					{sdl.CONTROLLER_BUTTON_A: invaders.P1Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+					{sdl.CONTROLLER_BUTTON_BACK: invaders.Coin},
+				},
+			},
+			"",
+		},
+		{
+			"keys swapped",
+			"p1-fire: C, pad:a\ncoin: X, Return, pad:back",
+			Keymap{
+				Keys: keys{sdl.SCANCODE_C: invaders.P1Fire, sdl.SCANCODE_X: invaders.Coin, sdl.SCANCODE_RETURN: invaders.Coin},
+				Pads: testKeymap().Pads,
+			},
+			"",
+		},
+		{"key listed twice", "coin: C, pad:back, C", testKeymap(), ""},
+		{"key kept by another control", "tilt: X", Keymap{}, `line 1: "X" is already bound to p1-fire`},
+		{"key listed for two controls", "tilt: Return\n\np1-fire: Space, Return", Keymap{}, `line 3: "Return" is already bound to tilt`},
+		{"button kept by another control", "p2-start: pad:back", Keymap{}, `line 1: "pad:back" is already bound to coin`},
+		{"button on the other pad", "p1-start: pad:a", Keymap{}, `line 1: "pad:a" is already bound to p1-fire`},
+		{
+			"button of the other player",
+			"p2-start: pad:x",
+			Keymap{
+				Keys: testKeymap().Keys,
+				Pads: [2]map[sdl.GameControllerButton]invaders.Input{
+					testKeymap().Pads[0],
+					{sdl.CONTROLLER_BUTTON_A: invaders.P2Fire, sdl.CONTROLLER_BUTTON_BACK: invaders.Coin, sdl.CONTROLLER_BUTTON_X: invaders.P2Start},
+				},
+			},
+			"",
+		},
+		{"no colon", "p1-fire Space", Keymap{}, "line 1: expected control: keys"},
+		{"unknown control", "# controls\np3-fire: Space", Keymap{}, `line 2: unknown control "p3-fire"`},
+		{"unknown key", "p1-fire: Space, Nope", Keymap{}, `line 1: unknown key "Nope"`},
+		{"unknown button", "p1-fire: pad:z", Keymap{}, `line 1: unknown controller button "pad:z"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := testKeymap()
+			keymap, err := ParseKeymap(strings.NewReader(test.text), base)
+			if !reflect.DeepEqual(base, testKeymap()) {
+				t.Errorf("base changed to %v", base)
+			}
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(keymap, test.keymap) {
+				t.Errorf("got %v, want %v", keymap, test.keymap)
+			}
+		})
+	}
+}
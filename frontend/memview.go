@@ -0,0 +1,293 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/protoshark/invaders8080/cpu"
+	"github.com/protoshark/invaders8080/invaders"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// memory viewer, a second window with a hex page of memory, a heatmap of the
+// accesses and the video RAM as the board sees it
+type memoryViewer struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	texture  *sdl.Texture
+	image    *image.RGBA
+
+	access *invaders.MemoryAccess
+
+	// values of the last frame and the frames left highlighting a change
+	previous [viewedMemory]uint8
+	changed  [viewedMemory]uint8
+
+	// accesses decaying over the frames
+	reads, writes [viewedMemory]float32
+
+	// first address of the hex page
+	page uint16
+}
+
+// the whole address space, shown a bank at a time on the heatmap
+const viewedMemory = 0x10000
+
+// Layout of the viewer, in pixels
+const (
+	viewerMargin = 4
+
+	hexRows    = 32
+	hexColumns = 16
+	hexPage    = hexRows * hexColumns
+	hexWidth   = (4 + hexColumns*3) * cellWidth
+	hexHeight  = (hexRows + 1) * cellHeight
+
+	heatmapWidth  = 128
+	heatmapHeight = 128
+	heatmapBank   = heatmapWidth * heatmapHeight
+	heatmapTop    = viewerMargin + hexHeight + viewerMargin
+
+	vramLeft   = viewerMargin + hexWidth + 2*viewerMargin
+	vramWidth  = 256
+	vramHeight = invaders.VisibleLines
+
+	viewerWidth  = vramLeft + vramWidth + viewerMargin
+	viewerHeight = heatmapTop + cellHeight + heatmapHeight + viewerMargin
+)
+
+// frames a changed byte stays highlighted, and the decay of the heatmap per
+// frame
+const (
+	changedFrames = 30
+	heatDecay     = 0.9
+)
+
+// Viewer colours
+var (
+	viewerText    = color.RGBA{0xa0, 0xa0, 0xa0, 0xff}
+	viewerTitle   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	viewerChanged = color.RGBA{0xff, 0x40, 0x40, 0xff}
+	viewerPixel   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	viewerCursor  = color.RGBA{0x40, 0x80, 0xff, 0xff}
+)
+
+// open or close the memory viewer
+func (front *Frontend) toggleMemoryViewer() {
+	if front.viewer != nil {
+		front.closeMemoryViewer()
+		return
+	}
+
+	viewer, err := newMemoryViewer(front.machine)
+	if err != nil {
+		fmt.Printf("Memory viewer failed: %s\n", err)
+		return
+	}
+	front.viewer = viewer
+	front.machine.WatchMemory(viewer.access)
+}
+
+func (front *Frontend) closeMemoryViewer() {
+	if front.viewer == nil {
+		return
+	}
+
+	front.machine.WatchMemory(nil)
+	front.viewer.destroy()
+	front.viewer = nil
+}
+
+func newMemoryViewer(machine *invaders.Machine) (*memoryViewer, error) {
+	viewer := &memoryViewer{
+		access: new(invaders.MemoryAccess),
+		image:  image.NewRGBA(image.Rect(0, 0, viewerWidth, viewerHeight)),
+		page:   cpu.RomOffset,
+	}
+	for addr := range viewer.previous {
+		viewer.previous[addr] = machine.Peek(uint16(addr))
+	}
+
+	window, err := sdl.CreateWindow("Memory", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		viewerWidth*2, viewerHeight*2, sdl.WINDOW_RESIZABLE)
+	if err != nil {
+		return nil, err
+	}
+	viewer.window = window
+
+	viewer.renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		viewer.destroy()
+		return nil, err
+	}
+	viewer.renderer.SetLogicalSize(viewerWidth, viewerHeight)
+
+	viewer.texture, err = viewer.renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_STREAMING,
+		viewerWidth, viewerHeight)
+	if err != nil {
+		viewer.destroy()
+		return nil, err
+	}
+
+	return viewer, nil
+}
+
+func (viewer *memoryViewer) destroy() {
+	if viewer.texture != nil {
+		viewer.texture.Destroy()
+	}
+	if viewer.renderer != nil {
+		viewer.renderer.Destroy()
+	}
+	if viewer.window != nil {
+		viewer.window.Destroy()
+	}
+}
+
+// whether an event of the window is for the viewer
+func (viewer *memoryViewer) owns(windowID uint32) bool {
+	id, err := viewer.window.GetID()
+	return err == nil && id == windowID
+}
+
+// move the hex page by pages, within the viewed memory
+func (front *Frontend) scrollMemory(pages int) {
+	if front.viewer == nil {
+		return
+	}
+
+	page := int(front.viewer.page) + pages*hexPage
+	if page < 0 {
+		page = 0
+	}
+	if page > viewedMemory-hexPage {
+		page = viewedMemory - hexPage
+	}
+	front.viewer.page = uint16(page)
+}
+
+// jump the hex page to the work RAM
+func (front *Frontend) homeMemory() {
+	if front.viewer != nil {
+		front.viewer.page = cpu.RomOffset
+	}
+}
+
+// take in the changes and accesses of the frame emulated last
+func (viewer *memoryViewer) update(machine *invaders.Machine) {
+	for addr := range viewer.previous {
+		value := machine.Peek(uint16(addr))
+		if value != viewer.previous[addr] {
+			viewer.previous[addr], viewer.changed[addr] = value, changedFrames
+		} else if viewer.changed[addr] > 0 {
+			viewer.changed[addr]--
+		}
+
+		viewer.reads[addr] = viewer.reads[addr]*heatDecay + float32(viewer.access.Reads[addr])
+		viewer.writes[addr] = viewer.writes[addr]*heatDecay + float32(viewer.access.Writes[addr])
+	}
+	viewer.access.Clear()
+}
+
+// draw and show the viewer
+func (viewer *memoryViewer) present(machine *invaders.Machine) {
+	img := viewer.image
+	for i := range img.Pix {
+		img.Pix[i] = 0
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+
+	viewer.drawHex()
+	viewer.drawHeatmap()
+	viewer.drawVRAM(machine)
+
+	viewer.texture.Update(nil, img.Pix, img.Stride)
+	viewer.renderer.Clear()
+	viewer.renderer.Copy(viewer.texture, nil, nil)
+	viewer.renderer.Present()
+}
+
+// hex page, bytes changed lately fade from red
+func (viewer *memoryViewer) drawHex() {
+	page := int(viewer.page)
+	title := fmt.Sprintf("MEMORY %04X-%04X  PGUP PGDN HOME", page, page+hexPage-1)
+	drawText(viewer.image, image.Pt(viewerMargin, viewerMargin), title, viewerTitle)
+
+	for row := 0; row < hexRows; row++ {
+		addr := page + row*hexColumns
+		y := viewerMargin + (row+1)*cellHeight
+		drawText(viewer.image, image.Pt(viewerMargin, y), fmt.Sprintf("%04X", addr), viewerTitle)
+
+		for col := 0; col < hexColumns; col++ {
+			a := addr + col
+			c := fade(viewerText, viewerChanged, float64(viewer.changed[a])/changedFrames)
+			x := viewerMargin + (5+col*3)*cellWidth
+			drawText(viewer.image, image.Pt(x, y), fmt.Sprintf("%02X", viewer.previous[a]), c)
+		}
+	}
+}
+
+// an address per pixel of the bank holding the hex page, green for the reads
+// and red for the writes, with the hex page framed
+func (viewer *memoryViewer) drawHeatmap() {
+	bank := int(viewer.page) / heatmapBank * heatmapBank
+	title := fmt.Sprintf("ACCESS %04X-%04X  READ WRITE", bank, bank+heatmapBank-1)
+	drawText(viewer.image, image.Pt(viewerMargin, heatmapTop), title, viewerTitle)
+
+	top := heatmapTop + cellHeight
+	for offset := 0; offset < heatmapBank; offset++ {
+		addr := bank + offset
+		viewer.image.SetRGBA(viewerMargin+offset%heatmapWidth, top+offset/heatmapWidth, color.RGBA{
+			R: heat(viewer.writes[addr]),
+			G: heat(viewer.reads[addr]),
+			A: 0xff,
+		})
+	}
+
+	first := top + (int(viewer.page)-bank)/heatmapWidth
+	last := first + hexPage/heatmapWidth - 1
+	for y := first; y <= last; y++ {
+		viewer.image.SetRGBA(viewerMargin-1, y, viewerCursor)
+		viewer.image.SetRGBA(viewerMargin+heatmapWidth, y, viewerCursor)
+	}
+}
+
+// video RAM as stored, a raster line per row with the first pixel of a byte
+// in its low bit
+func (viewer *memoryViewer) drawVRAM(machine *invaders.Machine) {
+	drawText(viewer.image, image.Pt(vramLeft, viewerMargin), "VRAM 2400-3FFF", viewerTitle)
+
+	top := viewerMargin + cellHeight
+	for offset := 0; offset < vramHeight*32; offset++ {
+		value := machine.Peek(uint16(cpu.VRAMOffset + offset))
+		for bit := 0; bit < 8; bit++ {
+			if value&(1<<uint(bit)) != 0 {
+				viewer.image.SetRGBA(vramLeft+offset%32*8+bit, top+offset/32, viewerPixel)
+			}
+		}
+	}
+}
+
+// brightness of the accesses per frame, on a log scale
+func heat(accesses float32) uint8 {
+	if accesses <= 0 {
+		return 0
+	}
+	level := 40 * math.Log2(1+float64(accesses))
+	if level > 0xff {
+		return 0xff
+	}
+	return uint8(level)
+}
+
+// colour between from and to, t from 0 to 1
+func fade(from, to color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
@@ -0,0 +1,194 @@
+package frontend
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// Input files hold the controls held during every frame, the emulation is
+// deterministic so playing one back reproduces the session
+//
+//	"INVM" version profile-name-length profile-name rom-hash-length rom-hash
+//	settings, see movieSettings
+//	controls, 16-bit little endian, one per frame, with movieReset set on
+//	the frames starting with a reset
+const (
+	movieMagic   = "INVM"
+	movieVersion = 3
+)
+
+// bit of the frame controls resetting the machine before the frame
+const movieReset = 1 << 15
+
+// settings of the machine a session depends on, little endian
+type movieSettings struct {
+	CPUClock     uint32 // Hz
+	RefreshRate  float64
+	TotalLines   uint16
+	Cocktail     bool
+	SwapControls bool
+	Phosphor     int64 // half-life in ns
+}
+
+// settings of the machine running
+func (front *Frontend) movieSettings() movieSettings {
+	config, cocktail := front.machine.Config(), front.machine.Cocktail()
+	return movieSettings{
+		CPUClock:     uint32(config.CPUClock),
+		RefreshRate:  config.RefreshRate,
+		TotalLines:   uint16(config.TotalLines),
+		Cocktail:     cocktail.Enabled,
+		SwapControls: cocktail.SwapControls,
+		Phosphor:     int64(front.machine.PhosphorHalfLife()),
+	}
+}
+
+// RecordInput writes the inputs of every frame to w, along with the ROM and
+// the settings of the machine
+func (front *Frontend) RecordInput(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	name, hash := front.machine.Profile().Name, front.machine.ROMHash()
+	header := append([]byte(movieMagic), movieVersion, uint8(len(name)))
+	header = append(header, name...)
+	header = append(header, uint8(len(hash)))
+	header = append(header, hash...)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, front.movieSettings()); err != nil {
+		return err
+	}
+
+	front.inputRecord = bw
+	return nil
+}
+
+// PlayInput replaces the inputs of every frame with those read from r, the
+// file must have been recorded with the same ROM and settings
+func (front *Frontend) PlayInput(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(movieMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return fmt.Errorf("reading input file: %w", err)
+	}
+	if string(header[:len(movieMagic)]) != movieMagic {
+		return fmt.Errorf("not an input file")
+	}
+	if version := header[len(movieMagic)]; version != movieVersion {
+		return fmt.Errorf("unsupported input file version %d", version)
+	}
+
+	name, err := readMovieString(br)
+	if err != nil {
+		return err
+	}
+	if name != front.machine.Profile().Name {
+		return fmt.Errorf("input file recorded with %s, not %s", name, front.machine.Profile().Name)
+	}
+
+	hash, err := readMovieString(br)
+	if err != nil {
+		return err
+	}
+	if hash != front.machine.ROMHash() {
+		return fmt.Errorf("input file recorded with the ROM %s, not %s", hash, front.machine.ROMHash())
+	}
+
+	var recorded movieSettings
+	if err := binary.Read(br, binary.LittleEndian, &recorded); err != nil {
+		return fmt.Errorf("reading input file: %w", err)
+	}
+	if err := recorded.match(front.movieSettings()); err != nil {
+		return err
+	}
+
+	front.inputPlayback = br
+	return nil
+}
+
+// string of the header, after its length
+func readMovieString(r *bufio.Reader) (string, error) {
+	n, err := r.ReadByte()
+	if err != nil {
+		return "", fmt.Errorf("reading input file: %w", err)
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", fmt.Errorf("reading input file: %w", err)
+	}
+	return string(s), nil
+}
+
+// the recorded settings against those of the machine, by their flags
+func (recorded movieSettings) match(current movieSettings) error {
+	switch {
+	case recorded.CPUClock != current.CPUClock:
+		return fmt.Errorf("input file recorded with -cpu-clock %d, not %d", recorded.CPUClock, current.CPUClock)
+	case recorded.RefreshRate != current.RefreshRate:
+		return fmt.Errorf("input file recorded with -refresh %g, not %g", recorded.RefreshRate, current.RefreshRate)
+	case recorded.TotalLines != current.TotalLines:
+		return fmt.Errorf("input file recorded with -lines %d, not %d", recorded.TotalLines, current.TotalLines)
+	case recorded.Cocktail != current.Cocktail:
+		return fmt.Errorf("input file recorded with -cocktail=%v", recorded.Cocktail)
+	case recorded.SwapControls != current.SwapControls:
+		return fmt.Errorf("input file recorded with -swap-controls=%v", recorded.SwapControls)
+	case recorded.Phosphor != current.Phosphor:
+		return fmt.Errorf("input file recorded with -phosphor %s, not %s",
+			time.Duration(recorded.Phosphor), time.Duration(current.Phosphor))
+	}
+	return nil
+}
+
+// Playing reports whether inputs are being played back
+func (front *Frontend) Playing() bool {
+	return front.inputPlayback != nil
+}
+
+// whether inputs are recorded or played back, the machine then runs from the
+// inputs alone
+func (front *Frontend) inMovie() bool {
+	return front.inputRecord != nil || front.inputPlayback != nil
+}
+
+// the controls of the frame about to run, played back or recorded. A reset
+// asked for is recorded with them and done before the frame
+func (front *Frontend) frameControls() invaders.Controls {
+	controls := front.stepAutofire(front.controls)
+	reset := front.resetPending
+	front.resetPending = false
+
+	if front.inputPlayback != nil {
+		var played uint16
+		if err := binary.Read(front.inputPlayback, binary.LittleEndian, &played); err != nil {
+			if err != io.EOF {
+				fmt.Printf("Input playback failed: %s\n", err)
+			}
+			front.inputPlayback = nil
+		} else {
+			controls, reset = invaders.Controls(played&^movieReset), played&movieReset != 0
+		}
+	}
+
+	if front.inputRecord != nil {
+		recorded := uint16(controls)
+		if reset {
+			recorded |= movieReset
+		}
+		if err := binary.Write(front.inputRecord, binary.LittleEndian, recorded); err != nil {
+			fmt.Printf("Input recording failed: %s\n", err)
+			front.inputRecord = nil
+		}
+	}
+
+	if reset {
+		front.machine.Reset()
+	}
+	return controls
+}
@@ -0,0 +1,136 @@
+package frontend
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+func newTestFrontend(t *testing.T, config invaders.Config, setup func(*invaders.Machine)) *Frontend {
+	t.Helper()
+	machine, err := invaders.NewMachine(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(machine)
+	}
+	return New(machine)
+}
+
+func TestMovieRoundTrip(t *testing.T) {
+	var file bytes.Buffer
+	front := newTestFrontend(t, invaders.DefaultConfig, nil)
+	if err := front.RecordInput(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	var coin invaders.Controls
+	coin.Set(invaders.Coin, true)
+	frames := []struct {
+		controls invaders.Controls
+		reset    bool
+	}{
+		{0, false},
+		{coin, false},
+		{0, true},
+		{coin, true},
+	}
+	for _, frame := range frames {
+		front.controls, front.resetPending = frame.controls, frame.reset
+		front.frameControls()
+	}
+	front.inputRecord.Flush()
+
+	playback := newTestFrontend(t, invaders.DefaultConfig, nil)
+	if err := playback.PlayInput(bytes.NewReader(file.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	for i, frame := range frames {
+		// a reset clears the RAM
+		playback.machine.Poke(0x2000, 0xff)
+		if got := playback.frameControls(); got != frame.controls {
+			t.Errorf("frame %d: got controls %04x, want %04x", i, got, frame.controls)
+		}
+		if reset := playback.machine.Peek(0x2000) == 0; reset != frame.reset {
+			t.Errorf("frame %d: reset %v, want %v", i, reset, frame.reset)
+		}
+	}
+	playback.frameControls()
+	if playback.Playing() {
+		t.Errorf("still playing after the last frame")
+	}
+}
+
+func TestMovieMismatch(t *testing.T) {
+	var file bytes.Buffer
+	front := newTestFrontend(t, invaders.DefaultConfig, nil)
+	if err := front.RecordInput(&file); err != nil {
+		t.Fatal(err)
+	}
+	front.inputRecord.Flush()
+
+	overclocked := invaders.DefaultConfig
+	overclocked.CPUClock *= 2
+
+	tests := []struct {
+		name   string
+		config invaders.Config
+		setup  func(*invaders.Machine)
+		err    string
+	}{
+		{"same", invaders.DefaultConfig, nil, ""},
+		{"cpu clock", overclocked, nil, "input file recorded with -cpu-clock 1996800, not 3993600"},
+		{"cocktail", invaders.DefaultConfig, func(m *invaders.Machine) {
+			m.SetCocktail(invaders.Cocktail{Enabled: true})
+		}, "input file recorded with -cocktail=false"},
+		{"swap", invaders.DefaultConfig, func(m *invaders.Machine) {
+			m.SetCocktail(invaders.Cocktail{SwapControls: true})
+		}, "input file recorded with -swap-controls=false"},
+		{"phosphor", invaders.DefaultConfig, func(m *invaders.Machine) {
+			m.SetPhosphorHalfLife(8 * time.Millisecond)
+		}, "input file recorded with -phosphor 0s, not 8ms"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			playback := newTestFrontend(t, test.config, test.setup)
+			err := playback.PlayInput(bytes.NewReader(file.Bytes()))
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.err != "" && (err == nil || err.Error() != test.err):
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestMovieHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  string
+	}{
+		{"empty", "", "reading input file: EOF"},
+		{"magic", "MOVIE", "not an input file"},
+		{"version", "INVM\x02", "unsupported input file version 2"},
+		{"game", "INVM\x03\x04galx", "input file recorded with galx, not invaders"},
+		{"rom", "INVM\x03\x08invaders\x02ab", "input file recorded with the ROM ab, not "},
+		{"truncated", "INVM\x03\x08invaders\x00\x01", "reading input file: unexpected EOF"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			front := newTestFrontend(t, invaders.DefaultConfig, nil)
+			err := front.PlayInput(bufio.NewReader(strings.NewReader(test.file)))
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
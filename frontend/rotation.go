@@ -0,0 +1,75 @@
+package frontend
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/protoshark/invaders8080/invaders"
+)
+
+// Rotation of the output frame, clockwise
+type Rotation int
+
+// Rotations
+const (
+	Rotate0 Rotation = iota
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+// ParseRotation from degrees
+func ParseRotation(degrees int) (Rotation, error) {
+	if degrees%90 != 0 || degrees < 0 || degrees >= 360 {
+		return 0, fmt.Errorf("rotation must be 0, 90, 180 or 270 degrees")
+	}
+	return Rotation(degrees / 90), nil
+}
+
+// SetRotation sets the rotation of the output, for vertical monitors
+func (front *Frontend) SetRotation(rotation Rotation) {
+	front.rotation = rotation
+}
+
+// screen size once rotated
+func (front *Frontend) outputSize() image.Point {
+	if front.rotation == Rotate90 || front.rotation == Rotate270 {
+		return image.Pt(int(invaders.ScreenHeight), int(invaders.ScreenWidth))
+	}
+	return image.Pt(int(invaders.ScreenWidth), int(invaders.ScreenHeight))
+}
+
+// rotate the frame by the manual rotation plus the cocktail flip
+func (front *Frontend) rotatedFrame(src *image.RGBA) *image.RGBA {
+	rotation := front.rotation
+	if front.machine.Flipped() {
+		rotation = (rotation + Rotate180) % 4
+	}
+	if rotation == Rotate0 {
+		return src
+	}
+
+	size := front.outputSize()
+	if front.rotated == nil || front.rotated.Rect.Size() != size {
+		front.rotated = image.NewRGBA(image.Rectangle{Max: size})
+	}
+
+	dst := front.rotated
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch rotation {
+			case Rotate90:
+				dx, dy = h-1-y, x
+			case Rotate180:
+				dx, dy = w-1-x, h-1-y
+			case Rotate270:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:])
+		}
+	}
+
+	return dst
+}
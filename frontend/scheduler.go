@@ -0,0 +1,136 @@
+package frontend
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// speeds stepped through by the speed keys, in percent
+var speedSteps = []int{50, 75, 100, 150, 200, 300, 400}
+
+// frames behind schedule after which it restarts from now instead of
+// catching up, after a stall or fast-forward
+const maxLag = 4
+
+// difference of the monitor refresh to the frame rate, relative to it, under
+// which vsync alone paces the frames, a 60 Hz monitor for the 59.54 Hz board
+const vsyncTolerance = 0.01
+
+// frame scheduler, frames are due at fixed deadlines so the sleeps do not
+// accumulate drift against the refresh rate
+type scheduler struct {
+	rate    float64 // frames per second at full speed
+	speed   int     // percent
+	vsync   bool
+	display float64 // refresh of the monitor showing the window, zero if unknown
+	next    time.Time
+	paused  bool
+	step    bool // run one frame while paused
+
+	// clock, time.Now and sleepUntil outside of the tests
+	now   func() time.Time
+	sleep func(deadline time.Time)
+}
+
+func newScheduler(rate float64) scheduler {
+	return scheduler{rate: rate, speed: 100, now: time.Now, sleep: sleepUntil}
+}
+
+// SetSpeed sets the emulation speed, from 50% to 400%
+func (front *Frontend) SetSpeed(percent int) error {
+	if percent < speedSteps[0] || percent > speedSteps[len(speedSteps)-1] {
+		return fmt.Errorf("speed must be between %d%% and %d%%", speedSteps[0], speedSteps[len(speedSteps)-1])
+	}
+	front.scheduler.speed = percent
+	return nil
+}
+
+// length of a frame at the current speed
+func (s *scheduler) period() time.Duration {
+	return time.Duration(float64(time.Second) / s.rate * 100 / float64(s.speed))
+}
+
+// wait until the next frame is due. With vsync on a monitor refreshing at
+// the frame rate presenting the frame already waits for it, on any other the
+// deadlines still pace the frames
+func (s *scheduler) wait() {
+	if s.vsyncPaced() {
+		return
+	}
+
+	period := s.period()
+	if now := s.now(); now.Sub(s.next) > maxLag*period {
+		s.next = now
+	}
+
+	s.sleep(s.next)
+	s.next = s.next.Add(period)
+}
+
+// whether presenting in step with the monitor runs at the frame rate
+func (s *scheduler) vsyncPaced() bool {
+	return s.vsync && s.speed == 100 && math.Abs(s.display-s.rate) <= vsyncTolerance*s.rate
+}
+
+// take the refresh of the monitor the window is on, again when it moves
+func (front *Frontend) updateDisplayRate() {
+	front.scheduler.display = 0
+	index, err := front.window.GetDisplayIndex()
+	if err != nil {
+		return
+	}
+	if mode, err := sdl.GetCurrentDisplayMode(index); err == nil {
+		front.scheduler.display = float64(mode.RefreshRate)
+	}
+}
+
+// whether the next frame runs, false while paused
+func (s *scheduler) running() bool {
+	if !s.paused {
+		return true
+	}
+	step := s.step
+	s.step = false
+	return step
+}
+
+// sleep until the deadline, the os may oversleep a little so the last
+// stretch is spun
+func sleepUntil(deadline time.Time) {
+	if d := time.Until(deadline) - time.Millisecond; d > 0 {
+		time.Sleep(d)
+	}
+	for time.Now().Before(deadline) {
+		runtime.Gosched()
+	}
+}
+
+func (front *Frontend) togglePause() {
+	front.scheduler.paused = !front.scheduler.paused
+	if front.scheduler.paused {
+		fmt.Println("Paused")
+	}
+}
+
+// run a single frame, pausing first if needed
+func (front *Frontend) advanceFrame() {
+	front.scheduler.paused, front.scheduler.step = true, true
+}
+
+// step the speed up or down
+func (front *Frontend) changeSpeed(step int) {
+	i := 0
+	for i < len(speedSteps)-1 && speedSteps[i] < front.scheduler.speed {
+		i++
+	}
+	if i += step; i < 0 || i >= len(speedSteps) {
+		return
+	}
+
+	front.scheduler.speed = speedSteps[i]
+	fmt.Printf("Speed %d%%\n", front.scheduler.speed)
+}
@@ -0,0 +1,113 @@
+package frontend
+
+import (
+	"testing"
+	"time"
+)
+
+// clock advanced by the test and by the sleeps, which oversleep by a fixed
+// time like a busy os
+type fakeClock struct {
+	t         time.Time
+	oversleep time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) sleep(deadline time.Time) {
+	if deadline.After(c.t) {
+		c.t = deadline.Add(c.oversleep)
+	}
+}
+
+func testScheduler(rate float64, speed int, clock *fakeClock) scheduler {
+	s := newScheduler(rate)
+	s.speed, s.now, s.sleep = speed, clock.now, clock.sleep
+	return s
+}
+
+func TestSchedulerDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      float64
+		speed     int
+		vsync     bool
+		display   float64
+		work      time.Duration // spent between the frames
+		oversleep time.Duration
+		frames    int
+		elapsed   time.Duration // by the last frame
+	}{
+		{"idle", 200, 100, false, 0, 0, 0, 41, 200 * time.Millisecond},
+		{"busy", 200, 100, false, 0, 3 * time.Millisecond, 0, 41, 200 * time.Millisecond},
+		{"late sleeps", 200, 100, false, 0, time.Millisecond, 2 * time.Millisecond, 41, 200 * time.Millisecond},
+		{"double speed", 100, 200, false, 0, time.Millisecond, 0, 41, 200 * time.Millisecond},
+		{"half speed", 200, 50, false, 0, 0, 0, 21, 200 * time.Millisecond},
+		{"vsync at 120 Hz", 200, 100, true, 120, 0, 0, 41, 200 * time.Millisecond},
+		{"vsync at the rate", 200, 100, true, 201, 0, 0, 41, 0},
+		{"vsync unknown display", 200, 100, true, 0, 0, 0, 41, 200 * time.Millisecond},
+		{"vsync slowed", 200, 50, true, 200, 0, 0, 21, 200 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(1000, 0), oversleep: test.oversleep}
+			s := testScheduler(test.rate, test.speed, clock)
+			s.vsync, s.display = test.vsync, test.display
+
+			var start time.Time
+			for i := 0; i < test.frames; i++ {
+				s.wait()
+				if i == 0 {
+					// the first frame starts the schedule
+					start = clock.t
+				}
+				clock.t = clock.t.Add(test.work)
+			}
+			elapsed := clock.t.Sub(start) - test.work
+
+			// a sleep may run late, but the delays do not add up
+			if want := test.elapsed; elapsed < want || elapsed > want+test.oversleep {
+				t.Errorf("%d frames took %s, want %s", test.frames, elapsed, want)
+			}
+		})
+	}
+}
+
+func TestSchedulerLag(t *testing.T) {
+	tests := []struct {
+		name  string
+		stall int // frames
+		free  int // frames run without waiting after the stall
+	}{
+		{"no stall", 0, 0},
+		{"short stall, caught up", 2, 2},
+		{"at the limit", maxLag + 1, maxLag + 1},
+		{"long stall, restarted", 10, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(1000, 0)}
+			s := testScheduler(200, 100, clock)
+			s.wait()
+
+			clock.t = clock.t.Add(time.Duration(test.stall) * s.period())
+			free := 0
+			for start := clock.t; ; free++ {
+				s.wait()
+				if clock.t != start {
+					if waited := clock.t.Sub(start); waited > s.period() {
+						t.Errorf("waited %s after the stall, at most %s", waited, s.period())
+					}
+					break
+				}
+			}
+			if free != test.free {
+				t.Errorf("%d frames run at once after a stall of %d, want %d", free, test.stall, test.free)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package gamestate decodes the Space Invaders work RAM into the state of
+// the game, for tools that need it without reading pixels
+package gamestate
+
+import "github.com/protoshark/invaders8080/cpu"
+
+// Memory the state is decoded from, a Machine satisfies it
+type Memory interface {
+	Peek(addr uint16) uint8
+}
+
+// Work RAM of the game at 0x2000-0x20ff, and the data of each player at
+// 0x2100 and 0x2200. Positions are in pixels as the game stores them, x
+// from the left and y from the bottom of the upright screen
+const (
+	rackY         = 0x2009
+	rackX         = 0x200a
+	rackDirection = 0x200d // 0 right, 1 left
+
+	playerAlive = 0x2015 // 0xff while alive
+	playerY     = 0x201a
+	playerX     = 0x201b
+
+	playerShotStatus = 0x2025
+	playerShotY      = 0x2029
+	playerShotX      = 0x202a
+
+	// rolling, plunger and squiggly shots, the position follows the status
+	alienShotStatus = 0x2035
+	alienShotStride = 0x10
+	alienShotSteps  = 1
+	alienShotY      = 8
+	alienShotX      = 9
+
+	currentPlayer = 0x2067 // high byte of the player data, 0x21 or 0x22
+	numAliens     = 0x2082
+
+	saucerActive   = 0x2084
+	saucerHit      = 0x2085
+	saucerLocation = 0x2087 // video RAM address, low byte first
+
+	credits = 0x20eb
+
+	// offsets in the player data
+	alienFlags = 0x00
+	rackCount  = 0xfe
+	numShips   = 0xff
+)
+
+// Scores in the work RAM, each one 4 BCD digits with the low ones first, and
+// the flag of the game being played
+const (
+	GameModeAddr = 0x20ef // 1 while a game is played
+	HiScoreAddr  = 0x20f4
+	P1ScoreAddr  = 0x20f8
+	P2ScoreAddr  = 0x20fc
+)
+
+// Rack size
+const (
+	AlienRows    = 5
+	AlienColumns = 11
+)
+
+// State of the game
+type State struct {
+	Playing bool // a game is on, otherwise the demo or the title screens
+	Player  int  // whose turn it is, 1 or 2
+	Credits int
+
+	Scores  [2]int
+	HiScore int
+	Players [2]Player
+
+	Ship   Ship
+	Rack   Rack
+	Shot   Shot    // of the player
+	Bombs  [3]Shot // of the aliens: rolling, plunger and squiggly
+	Saucer Saucer
+}
+
+// Player data kept across turns
+type Player struct {
+	Ships int // remaining, the one in play included
+	Wave  int // from 1
+}
+
+// Ship of the player
+type Ship struct {
+	X, Y  int
+	Alive bool
+}
+
+// Rack of aliens
+type Rack struct {
+	X, Y   int // of the bottom left alien
+	Left   bool
+	Count  int
+	Aliens [AlienRows][AlienColumns]bool // alive, bottom row first
+}
+
+// Shot of the player or an alien
+type Shot struct {
+	Active bool
+	Status uint8 // raw status byte
+	Steps  int   // moves since fired, aliens only
+	X, Y   int
+}
+
+// Saucer flying over the rack
+type Saucer struct {
+	Active bool
+	Hit    bool
+	X, Y   int
+}
+
+// Decode the state from memory
+func Decode(mem Memory) State {
+	var s State
+
+	s.Playing = mem.Peek(GameModeAddr) == 1
+	s.Player = 1
+	data := uint16(0x2100)
+	if mem.Peek(currentPlayer) == 0x22 {
+		s.Player, data = 2, 0x2200
+	}
+	s.Credits = BCD(mem.Peek(credits))
+
+	s.HiScore = Score(mem, HiScoreAddr)
+	s.Scores = [2]int{Score(mem, P1ScoreAddr), Score(mem, P2ScoreAddr)}
+	for i := range s.Players {
+		player := uint16(0x2100 + i*0x100)
+		s.Players[i] = Player{
+			Ships: int(mem.Peek(player + numShips)),
+			Wave:  int(mem.Peek(player+rackCount)) + 1,
+		}
+	}
+
+	s.Ship = Ship{
+		X:     int(mem.Peek(playerX)),
+		Y:     int(mem.Peek(playerY)),
+		Alive: mem.Peek(playerAlive) == 0xff,
+	}
+
+	s.Rack = Rack{
+		X:     int(mem.Peek(rackX)),
+		Y:     int(mem.Peek(rackY)),
+		Left:  mem.Peek(rackDirection) != 0,
+		Count: int(mem.Peek(numAliens)),
+	}
+	for row := 0; row < AlienRows; row++ {
+		for col := 0; col < AlienColumns; col++ {
+			s.Rack.Aliens[row][col] = mem.Peek(data+alienFlags+uint16(row*AlienColumns+col)) != 0
+		}
+	}
+
+	status := mem.Peek(playerShotStatus)
+	s.Shot = Shot{
+		Active: status != 0,
+		Status: status,
+		X:      int(mem.Peek(playerShotX)),
+		Y:      int(mem.Peek(playerShotY)),
+	}
+	for i := range s.Bombs {
+		addr := uint16(alienShotStatus + i*alienShotStride)
+		status := mem.Peek(addr)
+		s.Bombs[i] = Shot{
+			Active: status&0x80 != 0,
+			Status: status,
+			Steps:  int(mem.Peek(addr + alienShotSteps)),
+			X:      int(mem.Peek(addr + alienShotX)),
+			Y:      int(mem.Peek(addr + alienShotY)),
+		}
+	}
+
+	location := uint16(mem.Peek(saucerLocation)) | uint16(mem.Peek(saucerLocation+1))<<8
+	s.Saucer = Saucer{
+		Active: mem.Peek(saucerActive) != 0,
+		Hit:    mem.Peek(saucerHit) != 0,
+	}
+	if location >= cpu.VRAMOffset {
+		// a line of video RAM is a column of the upright screen, 32 bytes
+		// of 8 pixels from the bottom
+		offset := int(location - cpu.VRAMOffset)
+		s.Saucer.X, s.Saucer.Y = offset/32, offset%32*8
+	}
+
+	return s
+}
+
+// Score stored at addr as 4 BCD digits, the low ones first
+func Score(mem Memory, addr uint16) int {
+	return BCD(mem.Peek(addr+1))*100 + BCD(mem.Peek(addr))
+}
+
+// ScoreBytes are the bytes of a score as stored in RAM, the low digits first.
+// Scores roll over past 9999 like the game counters
+func ScoreBytes(score int) [2]uint8 {
+	return [2]uint8{toBCD(score % 100), toBCD(score / 100 % 100)}
+}
+
+// BCD value of a byte holding two digits
+func BCD(b uint8) int {
+	return int(b>>4)*10 + int(b&0x0f)
+}
+
+// two BCD digits of n from 0 to 99
+func toBCD(n int) uint8 {
+	return uint8(n/10<<4 | n%10)
+}
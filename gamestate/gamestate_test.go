@@ -0,0 +1,126 @@
+package gamestate
+
+import (
+	"reflect"
+	"testing"
+)
+
+// RAM image, the bytes not listed read 0
+type ram map[uint16]uint8
+
+func (mem ram) Peek(addr uint16) uint8 {
+	return mem[addr]
+}
+
+func TestDecode(t *testing.T) {
+	var player1Aliens, player2Aliens [AlienRows][AlienColumns]bool
+	player1Aliens[0][0], player1Aliens[1][1] = true, true
+	player2Aliens[4][10] = true
+
+	tests := []struct {
+		name  string
+		mem   ram
+		state State
+	}{
+		{
+			"power up",
+			ram{},
+			State{Player: 1, Players: [2]Player{{Wave: 1}, {Wave: 1}}},
+		},
+		{
+			"player 1 playing",
+			ram{
+				0x20ef: 1, 0x2067: 0x21, 0x20eb: 0x12,
+				0x20f4: 0x50, 0x20f5: 0x12, 0x20f8: 0x30, 0x20f9: 0x01,
+				0x21ff: 3, 0x21fe: 1, 0x22ff: 2,
+				0x2015: 0xff, 0x201a: 0x10, 0x201b: 0x30,
+				0x2009: 0x78, 0x200a: 0x40, 0x200d: 1, 0x2082: 2,
+				0x2100: 1, 0x210c: 1, 0x2236: 1,
+				0x2025: 1, 0x2029: 0x40, 0x202a: 0x31,
+				0x2035: 0x01, 0x2045: 0x81, 0x2046: 5, 0x204d: 0x60, 0x204e: 0x50,
+				0x2084: 1, 0x2087: 0x5d, 0x2088: 0x29,
+			},
+			State{
+				Playing: true,
+				Player:  1,
+				Credits: 12,
+				Scores:  [2]int{130, 0},
+				HiScore: 1250,
+				Players: [2]Player{{Ships: 3, Wave: 2}, {Ships: 2, Wave: 1}},
+				Ship:    Ship{X: 0x30, Y: 0x10, Alive: true},
+				Rack:    Rack{X: 0x40, Y: 0x78, Left: true, Count: 2, Aliens: player1Aliens},
+				Shot:    Shot{Active: true, Status: 1, X: 0x31, Y: 0x40},
+				Bombs:   [3]Shot{{Status: 0x01}, {Active: true, Status: 0x81, Steps: 5, X: 0x50, Y: 0x60}, {}},
+				Saucer:  Saucer{Active: true, X: 42, Y: 232},
+			},
+		},
+		{
+			"player 2 turn, saucer hit",
+			ram{
+				0x20ef: 1, 0x2067: 0x22,
+				0x20fc: 0x99, 0x20fd: 0x99,
+				0x21ff: 0, 0x22ff: 1, 0x22fe: 9,
+				0x2100: 1, 0x210c: 1, 0x2236: 1, 0x2082: 1,
+				0x2085: 1, 0x2087: 0xff, 0x2088: 0x23,
+			},
+			State{
+				Playing: true,
+				Player:  2,
+				Scores:  [2]int{0, 9999},
+				Players: [2]Player{{Ships: 0, Wave: 1}, {Ships: 1, Wave: 10}},
+				Rack:    Rack{Count: 1, Aliens: player2Aliens},
+				Saucer:  Saucer{Hit: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if state := Decode(test.mem); !reflect.DeepEqual(state, test.state) {
+				t.Errorf("got %+v\nwant %+v", state, test.state)
+			}
+		})
+	}
+}
+
+func TestBCD(t *testing.T) {
+	tests := []struct {
+		b uint8
+		n int
+	}{
+		{0x00, 0}, {0x09, 9}, {0x10, 10}, {0x42, 42}, {0x99, 99},
+	}
+
+	for _, test := range tests {
+		if got := BCD(test.b); got != test.n {
+			t.Errorf("BCD(%02x) = %d, want %d", test.b, got, test.n)
+		}
+		if got := toBCD(test.n); got != test.b {
+			t.Errorf("toBCD(%d) = %02x, want %02x", test.n, got, test.b)
+		}
+	}
+}
+
+func TestScoreBytes(t *testing.T) {
+	tests := []struct {
+		score int
+		bytes [2]uint8
+	}{
+		{0, [2]uint8{0x00, 0x00}},
+		{40, [2]uint8{0x40, 0x00}},
+		{1230, [2]uint8{0x30, 0x12}},
+		{9999, [2]uint8{0x99, 0x99}},
+		{10050, [2]uint8{0x50, 0x00}},
+	}
+
+	for _, test := range tests {
+		got := ScoreBytes(test.score)
+		if got != test.bytes {
+			t.Errorf("ScoreBytes(%d) = %02x, want %02x", test.score, got, test.bytes)
+		}
+		mem := ram{P1ScoreAddr: got[0], P1ScoreAddr + 1: got[1]}
+		if score := Score(mem, P1ScoreAddr); score != test.score%10000 {
+			t.Errorf("Score of %02x = %d, want %d", got, score, test.score%10000)
+		}
+	}
+}
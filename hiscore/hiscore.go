@@ -0,0 +1,124 @@
+// Package hiscore keeps the hi-score and a table of the best scores of a ROM
+// set across sessions, as the board has no memory surviving power off
+package hiscore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// TableSize is the number of scores kept in the table
+const TableSize = 10
+
+// InitialsLength is the number of letters of the initials
+const InitialsLength = 3
+
+// Entry of the table
+type Entry struct {
+	Initials string `json:"initials"`
+	Score    int    `json:"score"`
+}
+
+// Table of a ROM set, the hi-score is kept even without initials entered
+type Table struct {
+	HiScore int     `json:"hiscore"`
+	Entries []Entry `json:"entries,omitempty"`
+}
+
+// Qualifies reports whether the score enters the table
+func (t *Table) Qualifies(score int) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(t.Entries) < TableSize || score > t.Entries[len(t.Entries)-1].Score
+}
+
+// Add the score to the table, it returns its rank from 1, or 0 when it does
+// not qualify
+func (t *Table) Add(initials string, score int) int {
+	if score > t.HiScore {
+		t.HiScore = score
+	}
+	if !t.Qualifies(score) {
+		return 0
+	}
+
+	// after the equal scores, the older entries keep their rank
+	rank := sort.Search(len(t.Entries), func(i int) bool { return t.Entries[i].Score < score })
+	t.Entries = append(t.Entries, Entry{})
+	copy(t.Entries[rank+1:], t.Entries[rank:])
+	t.Entries[rank] = Entry{Initials: initials, Score: score}
+	if len(t.Entries) > TableSize {
+		t.Entries = t.Entries[:TableSize]
+	}
+
+	return rank + 1
+}
+
+// Load the table of a file, an empty one when the file does not exist
+func Load(path string) (*Table, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Table{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// Save the table to a file, creating its directory
+func (t *Table) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Path of the table of a ROM set in dir, by the hash of the ROM
+func Path(dir, romHash string) string {
+	return filepath.Join(dir, romHash+".json")
+}
+
+// DefaultDir is the directory of the tables in the user data directory
+func DefaultDir() (string, error) {
+	var data string
+	switch runtime.GOOS {
+	case "windows":
+		data = os.Getenv("LocalAppData")
+	case "darwin", "ios":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, "Library", "Application Support")
+	default:
+		data = os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			data = filepath.Join(home, ".local", "share")
+		}
+	}
+	if data == "" {
+		return "", fmt.Errorf("no user data directory")
+	}
+
+	return filepath.Join(data, "invaders8080", "hiscores"), nil
+}
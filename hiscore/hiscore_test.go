@@ -0,0 +1,100 @@
+package hiscore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTableAdd(t *testing.T) {
+	full := &Table{}
+	for i := 0; i < TableSize; i++ {
+		full.Entries = append(full.Entries, Entry{"AAA", 1000 - i*100})
+	}
+
+	tests := []struct {
+		name    string
+		table   *Table
+		score   int
+		rank    int
+		entries []Entry
+	}{
+		{"empty", &Table{}, 100, 1, []Entry{{"NEW", 100}}},
+		{"zero", &Table{}, 0, 0, nil},
+		{"best", &Table{Entries: []Entry{{"AAA", 200}}}, 300, 1, []Entry{{"NEW", 300}, {"AAA", 200}}},
+		{"last", &Table{Entries: []Entry{{"AAA", 200}}}, 100, 2, []Entry{{"AAA", 200}, {"NEW", 100}}},
+		{
+			"tie after the older",
+			&Table{Entries: []Entry{{"AAA", 300}, {"BBB", 200}, {"CCC", 100}}},
+			200, 3,
+			[]Entry{{"AAA", 300}, {"BBB", 200}, {"NEW", 200}, {"CCC", 100}},
+		},
+		{"full, drops the last", full, 150, 10, append(append([]Entry(nil), full.Entries[:9]...), Entry{"NEW", 150})},
+		{"full, tie with the last", full, 100, 0, full.Entries},
+		{"full, below the last", full, 50, 0, full.Entries},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := &Table{Entries: append([]Entry(nil), test.table.Entries...)}
+			qualifies := table.Qualifies(test.score)
+			if qualifies != (test.rank > 0) {
+				t.Errorf("Qualifies(%d) = %v", test.score, qualifies)
+			}
+
+			if rank := table.Add("NEW", test.score); rank != test.rank {
+				t.Errorf("Add(%d) = %d, want %d", test.score, rank, test.rank)
+			}
+			if !reflect.DeepEqual(table.Entries, test.entries) {
+				t.Errorf("entries %v, want %v", table.Entries, test.entries)
+			}
+			if len(table.Entries) > TableSize {
+				t.Errorf("%d entries, at most %d", len(table.Entries), TableSize)
+			}
+		})
+	}
+}
+
+func TestTableHiScore(t *testing.T) {
+	table := &Table{HiScore: 500, Entries: []Entry{{"AAA", 100}}}
+
+	table.Add("BBB", 300)
+	if table.HiScore != 500 {
+		t.Errorf("hi-score lowered to %d", table.HiScore)
+	}
+	table.Add("CCC", 700)
+	if table.HiScore != 700 {
+		t.Errorf("hi-score %d, want 700", table.HiScore)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hiscore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hiscores", Path("", "0123abcd"))
+
+	empty, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading a missing file: %v", err)
+	}
+	if !reflect.DeepEqual(empty, &Table{}) {
+		t.Errorf("missing file loaded as %v", empty)
+	}
+
+	table := &Table{HiScore: 1230, Entries: []Entry{{"ABC", 1230}, {"XYZ", 40}}}
+	if err := table.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, table) {
+		t.Errorf("loaded %v, want %v", loaded, table)
+	}
+}
@@ -0,0 +1,49 @@
+package invaders
+
+// Cocktail cabinet, the players sit face to face and the screen is flipped
+// during player 2's turn
+type Cocktail struct {
+	Enabled bool
+	// player 1 controls drive player 2 while the screen is flipped, for a
+	// single set of controls
+	SwapControls bool
+}
+
+// player controls swapped on the flipped screen
+var swappedControls = map[Input]Input{
+	P1Fire:  P2Fire,
+	P1Left:  P2Left,
+	P1Right: P2Right,
+}
+
+// SetCocktail sets the cabinet to cocktail mode
+func (machine *Machine) SetCocktail(cocktail Cocktail) {
+	machine.cocktail = cocktail
+}
+
+// Cocktail settings of the cabinet
+func (machine *Machine) Cocktail() Cocktail {
+	return machine.cocktail
+}
+
+// Flipped reports whether the cocktail screen is flipped for player 2, the
+// frame must then be shown upside down
+func (machine *Machine) Flipped() bool {
+	return machine.flipped
+}
+
+// update the screen flip after a write to the flip port
+func (machine *Machine) updateFlip() {
+	flip := machine.profile.Ports.Flip
+	machine.flipped = machine.cocktail.Enabled && machine.outputs[flip.Port]&flip.Mask != 0
+}
+
+// control driven by the input once the cocktail swap is applied
+func (machine *Machine) routeInput(input Input) Input {
+	if machine.flipped && machine.cocktail.SwapControls {
+		if swapped, ok := swappedControls[input]; ok {
+			return swapped
+		}
+	}
+	return input
+}
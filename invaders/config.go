@@ -0,0 +1,61 @@
+package invaders
+
+import (
+	"fmt"
+	"math"
+)
+
+// Config of the machine, the game and its timing
+type Config struct {
+	Profile *Profile // nil for DefaultProfile
+
+	CPUClock    int     // Hz
+	RefreshRate float64 // Hz
+	TotalLines  int     // scanlines per frame, visible ones included
+}
+
+// DefaultConfig of the Space Invaders board, a 19.968 MHz crystal clocks the
+// cpu at 1.9968 MHz and the beam over 262 lines of 320 pixels, so a frame
+// lasts 33536 cycles and the monitor refreshes at 59.54 Hz
+var DefaultConfig = Config{
+	Profile:     DefaultProfile,
+	CPUClock:    1996800,
+	RefreshRate: 1996800. / 33536,
+	TotalLines:  262,
+}
+
+// Limits of the timing, well past any board but keeping a frame cheap
+const (
+	MaxCPUClock       = 20000000 // Hz, 10 times the original
+	MaxTotalLines     = 1024
+	MaxCyclesPerFrame = 1 << 20
+)
+
+// Validate checks the timing can drive the raster
+func (config Config) Validate() error {
+	switch {
+	case config.CPUClock <= 0 || config.CPUClock > MaxCPUClock:
+		return fmt.Errorf("cpu clock must be between 1 Hz and %d Hz, got %d", MaxCPUClock, config.CPUClock)
+	case config.RefreshRate < 1 || config.RefreshRate > 1000:
+		return fmt.Errorf("refresh rate must be between 1 and 1000 Hz, got %g", config.RefreshRate)
+	case config.TotalLines <= VisibleLines || config.TotalLines > MaxTotalLines:
+		return fmt.Errorf("a frame needs more than the %d visible lines and at most %d, got %d",
+			VisibleLines, MaxTotalLines, config.TotalLines)
+	case config.CyclesPerFrame() < config.TotalLines:
+		return fmt.Errorf("%d Hz is too slow to run a cycle per line at %.2f Hz", config.CPUClock, config.RefreshRate)
+	case config.CyclesPerFrame() > MaxCyclesPerFrame:
+		return fmt.Errorf("a frame of %d cycles is too long, at most %d", config.CyclesPerFrame(), MaxCyclesPerFrame)
+	}
+	return nil
+}
+
+// CyclesPerFrame run by the cpu, rounded to whole cycles
+func (config Config) CyclesPerFrame() int {
+	return int(math.Round(float64(config.CPUClock) / config.RefreshRate))
+}
+
+// FrameRate emulated, the refresh rate once the frame is rounded to whole
+// cycles
+func (config Config) FrameRate() float64 {
+	return float64(config.CPUClock) / float64(config.CyclesPerFrame())
+}
@@ -0,0 +1,79 @@
+package invaders
+
+import "github.com/protoshark/invaders8080/gamestate"
+
+// ScoreLayout is where a game keeps its scores in RAM, each one 4 BCD digits
+// with the low ones first
+type ScoreLayout struct {
+	GameMode uint16 // 1 while a game is played
+	HiScore  uint16
+	Scores   [2]uint16 // of the players
+}
+
+// Scores of Space Invaders
+var invadersScores = &ScoreLayout{
+	GameMode: gamestate.GameModeAddr,
+	HiScore:  gamestate.HiScoreAddr,
+	Scores:   [2]uint16{gamestate.P1ScoreAddr, gamestate.P2ScoreAddr},
+}
+
+// ScoresSupported reports whether the RAM layout of the scores of the game
+// is known, SetHiScore and OnGameOver need it
+func (machine *Machine) ScoresSupported() bool {
+	return machine.profile.Scores != nil
+}
+
+// SetHiScore keeps the hi-score across power ups, the board having no memory
+// surviving them. It is written into RAM while the game boots or runs its
+// attract mode, again after a reset, and follows the games played from then
+// on
+func (machine *Machine) SetHiScore(score int) {
+	machine.hiScore, machine.keepHiScore = score, true
+}
+
+// OnGameOver sets the hook called at the end of every game with the scores
+// of the players and the hi-score, nil removes it
+func (machine *Machine) OnGameOver(hook func(scores [2]int, hiScore int)) {
+	machine.onGameOver = hook
+}
+
+// follow the games after a frame, reporting those ending and putting the
+// hi-score back outside of them
+func (machine *Machine) followScores() {
+	layout := machine.profile.Scores
+	if layout == nil {
+		return
+	}
+
+	playing := machine.Peek(layout.GameMode) == 1
+	ended := machine.playing && !playing
+	machine.playing = playing
+
+	hiScore := machine.readScore(layout.HiScore)
+	if machine.keepHiScore {
+		if hiScore > machine.hiScore {
+			machine.hiScore = hiScore
+		}
+		// the game only raises the hi-score at the end of a game, outside
+		// of one it is safe to write
+		if !playing && hiScore < machine.hiScore {
+			machine.writeScore(layout.HiScore, machine.hiScore)
+			hiScore = machine.hiScore
+		}
+	}
+
+	if ended && machine.onGameOver != nil {
+		scores := [2]int{machine.readScore(layout.Scores[0]), machine.readScore(layout.Scores[1])}
+		machine.onGameOver(scores, hiScore)
+	}
+}
+
+func (machine *Machine) readScore(addr uint16) int {
+	return gamestate.Score(machine, addr)
+}
+
+func (machine *Machine) writeScore(addr uint16, score int) {
+	for i, b := range gamestate.ScoreBytes(score) {
+		machine.Poke(addr+uint16(i), b)
+	}
+}
@@ -0,0 +1,77 @@
+package invaders
+
+import "testing"
+
+func TestScores(t *testing.T) {
+	machine, err := NewMachine(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// JMP to itself, the test plays the game by poking its RAM
+	for i, b := range []byte{0xc3, 0x00, 0x00} {
+		machine.Poke(uint16(i), b)
+	}
+
+	var ended [][2]int
+	var hiScores []int
+	machine.OnGameOver(func(scores [2]int, hiScore int) {
+		ended = append(ended, scores)
+		hiScores = append(hiScores, hiScore)
+	})
+	layout := machine.profile.Scores
+
+	// written while the game boots
+	machine.SetHiScore(1230)
+	machine.StepFrame(0)
+	if got := machine.readScore(layout.HiScore); got != 1230 {
+		t.Fatalf("hi-score at boot: got %d, want 1230", got)
+	}
+
+	// left alone during a game
+	machine.Poke(layout.GameMode, 1)
+	machine.writeScore(layout.HiScore, 0)
+	machine.StepFrame(0)
+	if got := machine.readScore(layout.HiScore); got != 0 {
+		t.Errorf("hi-score written during a game: got %d", got)
+	}
+	if len(ended) != 0 {
+		t.Errorf("game over reported during the game")
+	}
+
+	// the game raises it when it ends
+	machine.writeScore(layout.Scores[0], 2500)
+	machine.writeScore(layout.Scores[1], 40)
+	machine.writeScore(layout.HiScore, 2500)
+	machine.Poke(layout.GameMode, 0)
+	machine.StepFrame(0)
+	machine.StepFrame(0)
+	if len(ended) != 1 || ended[0] != [2]int{2500, 40} || hiScores[0] != 2500 {
+		t.Errorf("game over: got %v %v, want [[2500 40]] [2500]", ended, hiScores)
+	}
+
+	// and it is written back after a reset
+	machine.Reset()
+	for i, b := range []byte{0xc3, 0x00, 0x00} {
+		machine.Poke(uint16(i), b)
+	}
+	machine.StepFrame(0)
+	if got := machine.readScore(layout.HiScore); got != 2500 {
+		t.Errorf("hi-score after a reset: got %d, want 2500", got)
+	}
+}
+
+func TestScoresUnset(t *testing.T) {
+	machine, err := NewMachine(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range []byte{0xc3, 0x00, 0x00} {
+		machine.Poke(uint16(i), b)
+	}
+
+	// without SetHiScore the RAM is the game's alone
+	machine.StepFrame(0)
+	if got := machine.readScore(machine.profile.Scores.HiScore); got != 0 {
+		t.Errorf("hi-score written: got %d", got)
+	}
+}
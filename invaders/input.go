@@ -0,0 +1,92 @@
+package invaders
+
+import "fmt"
+
+// Input is a cabinet control wired to the input ports
+type Input uint8
+
+// Cabinet controls
+const (
+	Coin Input = iota
+	P1Start
+	P2Start
+	P1Fire
+	P1Left
+	P1Right
+	P2Fire
+	P2Left
+	P2Right
+	Tilt
+	Service
+)
+
+var inputNames = [...]string{
+	"coin", "p1-start", "p2-start",
+	"p1-fire", "p1-left", "p1-right",
+	"p2-fire", "p2-left", "p2-right",
+	"tilt", "service",
+}
+
+func (input Input) String() string {
+	return inputNames[input]
+}
+
+// ParseInput from its name
+func ParseInput(name string) (Input, error) {
+	for input, inputName := range inputNames {
+		if inputName == name {
+			return Input(input), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown control %q", name)
+}
+
+// Input wiring of the Space Invaders board, some revisions read the player 1
+// controls from port 0 instead of port 1, so they are wired to both
+var invadersInputs = map[Input][]PortBit{
+	Coin:    {{1, 1 << 0}},
+	P2Start: {{1, 1 << 1}},
+	P1Start: {{1, 1 << 2}},
+	P1Fire:  {{1, 1 << 4}, {0, 1 << 4}},
+	P1Left:  {{1, 1 << 5}, {0, 1 << 5}},
+	P1Right: {{1, 1 << 6}, {0, 1 << 6}},
+	P2Fire:  {{2, 1 << 4}},
+	P2Left:  {{2, 1 << 5}},
+	P2Right: {{2, 1 << 6}},
+	Tilt:    {{2, 1 << 2}},
+	Service: {{0, 1 << 0}}, // DIP4, self-test request read at power up
+}
+
+// Port 0 bits 1-3 are tied high on the board
+const port0Default uint8 = 0x0e
+
+// Controls held during a frame, a bit per Input
+type Controls uint16
+
+// Pressed reports whether the control is held
+func (controls Controls) Pressed(input Input) bool {
+	return controls&(1<<input) != 0
+}
+
+// Set presses or releases a control
+func (controls *Controls) Set(input Input, pressed bool) {
+	if pressed {
+		*controls |= 1 << input
+	} else {
+		*controls &^= 1 << input
+	}
+}
+
+// drive the input ports from the controls held, over the idle levels
+func (machine *Machine) applyControls(controls Controls) {
+	copy(machine.ports[:], machine.profile.Ports.Defaults[:])
+
+	for input := range inputNames {
+		if !controls.Pressed(Input(input)) {
+			continue
+		}
+		for _, line := range machine.profile.Ports.Inputs[machine.routeInput(Input(input))] {
+			machine.ports[line.Port] |= line.Mask
+		}
+	}
+}
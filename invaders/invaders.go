@@ -1,284 +1,280 @@
 package invaders
 
 import (
-	"fmt"
+	"image"
+	"image/color"
 
 	"github.com/protoshark/invaders8080/cpu"
-	"github.com/veandco/go-sdl2/sdl"
 )
 
-// Invaders game struct
-type Invaders struct {
+// Machine is the Midway 8080 board running one of the games, it is driven a
+// frame at a time by its caller
+type Machine struct {
 	cpu         cpu.CPU
-	renderer    *sdl.Renderer
-	texture     *sdl.Texture
-	frameBuffer []uint8
+	frameBuffer *image.RGBA
 
-	ports [9]uint8
+	profile *Profile
+	config  Config
+	romHash string
+
+	// colour of a lit pixel, the overlays applied over white
+	tint []color.RGBA
+	// monitor persistence, nil for hard on/off pixels
+	phosphor *phosphor
+
+	// cocktail cabinet, flipped during player 2's turn
+	cocktail Cocktail
+	flipped  bool
+
+	sound *sound
+
+	// memory accesses counted for debuggers, nil when nobody watches
+	access *MemoryAccess
+
+	// hi-score kept across resets, whether a game is on and the hook told
+	// when it ends
+	hiScore     int
+	keepHiScore bool
+	playing     bool
+	onGameOver  func(scores [2]int, hiScore int)
+
+	ports   [9]uint8 // IN
+	outputs [9]uint8 // OUT
 
 	shiftOffset   uint8
 	shiftRegister uint16
 }
 
-const InitialROMOffset uint32 = 0x0000
-
 // Screen dimensions
 const (
 	ScreenWidth  int32 = 224
 	ScreenHeight int32 = 256
 )
 
-// Machine config
-var (
-	FPS             = 60
-	Frames          = 1000. / FPS
-	CyclesPerFrames = 2000 * Frames
+// Raster timing, the beam draws the first 224 lines of a frame. The
+// mid-screen interrupt fires when it reaches line 96
+const (
+	VisibleLines  = 224
+	MidScreenLine = 96
 )
 
-// New Invaders
-func New() Invaders {
-	game := Invaders{
-		cpu: cpu.New(),
+// NewMachine running the game of the config, the ROM must be loaded before
+// the first frame
+func NewMachine(config Config) (*Machine, error) {
+	if config.Profile == nil {
+		config.Profile = DefaultProfile
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
-	game.frameBuffer = make([]uint8, ScreenWidth*ScreenHeight*4)
-
-	// game.ports[1] = 1 << 3
 
-	return game
-}
+	machine := &Machine{
+		cpu:     cpu.New(),
+		profile: config.Profile,
+		config:  config,
+		sound:   newSound(config.Profile.sound),
+	}
+	machine.frameBuffer = image.NewRGBA(image.Rect(0, 0, int(ScreenWidth), int(ScreenHeight)))
+	machine.tint = make([]color.RGBA, ScreenWidth*ScreenHeight)
 
-// Load space invaders into cpu memory
-func (game *Invaders) loadROM(romPath string) {
-	file := sdl.RWFromFile(romPath, "rb")
-	defer file.Close()
+	machine.cpu.ROM, machine.cpu.RAM = machine.profile.memoryMap()
+	machine.SetOverlays(machine.profile.Video.Overlays)
 
-	fmt.Printf("Loading %s\n", romPath)
+	copy(machine.ports[:], machine.profile.Ports.Defaults[:])
 
-	file.Read(game.cpu.Memory[InitialROMOffset:])
+	return machine, nil
 }
 
-// Run space invaders
-func (game *Invaders) Run(romPath string) {
-	game.setup(romPath)
-	defer sdl.Quit()
-	defer game.renderer.Destroy()
-	defer game.texture.Destroy()
+// Profile of the game running
+func (machine *Machine) Profile() *Profile {
+	return machine.profile
+}
 
-	// start the timer
-	timer := sdl.GetTicks()
+// Config of the machine
+func (machine *Machine) Config() Config {
+	return machine.config
+}
 
-	running := true
-	for running {
-		// running = game.handleEvents()
+// StepFrame emulates a frame with the controls held, it returns the frame
+// drawn and the sound samples generated meanwhile. The frame is reused by
+// the next call
+func (machine *Machine) StepFrame(controls Controls) (*image.RGBA, []int16) {
+	machine.applyControls(controls)
+	machine.runFrame()
+	machine.followScores()
+	return machine.frameBuffer, machine.sound.drain()
+}
 
-		if sdl.GetTicks()-timer >= uint32(Frames)/2 {
-			game.update()
-			if game.cpu.IntEnable {
-				game.cpu.Interrupt(0x08)
-			}
-			game.update()
-			running = game.handleEvents()
-			game.draw()
+// Peek reads a byte of memory without side effects
+func (machine *Machine) Peek(addr uint16) uint8 {
+	return machine.cpu.Memory[addr]
+}
 
-			if game.cpu.IntEnable {
-				game.cpu.Interrupt(0x10)
-			}
+// Poke writes a byte of memory without side effects, ROM included
+func (machine *Machine) Poke(addr uint16, value uint8) {
+	machine.cpu.Memory[addr] = value
+}
 
-			timer = sdl.GetTicks()
-		}
-	}
+// Registers of the cpu
+type Registers struct {
+	A, B, C, D, E, H, L uint8
+	Flags               uint8
+	PC, SP              uint16
+	IntEnable           bool
 }
 
-func (game *Invaders) setup(romPath string) {
-	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
-		panic(err)
+// Registers of the cpu between frames
+func (machine *Machine) Registers() Registers {
+	c := &machine.cpu
+	return Registers{
+		A: c.A, B: c.B, C: c.C, D: c.D, E: c.E, H: c.H, L: c.L,
+		Flags: uint8(c.Flags),
+		PC:    c.PC, SP: c.SP,
+		IntEnable: c.IntEnable,
 	}
+}
 
-	// create the window
-	window, err := sdl.CreateWindow("Space Invaders", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
-		ScreenWidth*2, ScreenHeight*2, sdl.WINDOW_RESIZABLE)
+// Frame drawn last
+func (machine *Machine) Frame() *image.RGBA {
+	return machine.frameBuffer
+}
 
-	if err != nil {
-		sdl.Quit()
-		panic(err)
+// Reset the board as at power up, the ROM stays loaded
+func (machine *Machine) Reset() {
+	memory := machine.cpu.Memory
+	for _, r := range machine.cpu.RAM {
+		for addr := int(r.Start); addr <= int(r.End); addr++ {
+			memory[addr] = 0
+		}
 	}
 
-	// set minimum size
-	window.SetMinimumSize(ScreenWidth, ScreenHeight)
+	rom, ram := machine.cpu.ROM, machine.cpu.RAM
+	machine.cpu = cpu.CPU{Memory: memory, ROM: rom, RAM: ram}
+	machine.installWatch()
 
-	// hide cursor
-	sdl.ShowCursor(sdl.DISABLE)
+	machine.ports, machine.outputs = [9]uint8{}, [9]uint8{}
+	copy(machine.ports[:], machine.profile.Ports.Defaults[:])
+	machine.shiftOffset, machine.shiftRegister = 0, 0
+	machine.flipped = false
+	machine.playing = false
 
-	game.renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
-	if err != nil {
-		window.Destroy()
-		sdl.Quit()
-		panic(err)
+	machine.sound = newSound(machine.profile.sound)
+	if machine.phosphor != nil {
+		for i := range machine.phosphor.intensity {
+			machine.phosphor.intensity[i] = 0
+		}
 	}
+}
 
-	if game.renderer.SetLogicalSize(ScreenWidth, ScreenHeight) != nil {
-		game.renderer.Destroy()
-		window.Destroy()
-		sdl.Quit()
-		panic(err)
+// OUT instruction
+func (machine *Machine) handleOut(port uint8) {
+	wiring := &machine.profile.Ports
+
+	switch port {
+	case wiring.ShiftAmount:
+		machine.shiftOffset = machine.cpu.A & 0x07
+	case wiring.ShiftData:
+		machine.shiftRegister = (uint16(machine.cpu.A) << 8) | (machine.shiftRegister >> 8)
+	default:
+		// nothing is wired past the ports of the board
+		if int(port) >= len(machine.outputs) {
+			return
+		}
+		machine.outputs[port] = machine.cpu.A
+		machine.sound.write(port, machine.cpu.A)
+		if port == wiring.Flip.Port {
+			machine.updateFlip()
+		}
 	}
+}
 
-	game.texture, err = game.renderer.CreateTexture(sdl.PIXELFORMAT_RGB888, sdl.TEXTUREACCESS_STREAMING, ScreenWidth, ScreenHeight)
-
-	if err != nil {
-		game.renderer.Destroy()
-		window.Destroy()
-		sdl.Quit()
-		panic(err)
+// IN instruction
+func (machine *Machine) handleIn(port uint8) {
+	if port == machine.profile.Ports.ShiftResult {
+		machine.cpu.A = uint8(machine.shiftRegister >> (8 - machine.shiftOffset))
+		return
 	}
 
-	game.updateScreen()
-	game.loadROM(romPath)
-}
-
-func (game *Invaders) updateScreen() {
-	game.texture.Update(nil, game.frameBuffer, 4*int(ScreenWidth))
-	game.renderer.Clear()
-	game.renderer.Copy(game.texture, nil, nil)
-	game.renderer.Present()
+	// unconnected ports read as 0
+	machine.cpu.A = 0
+	if int(port) < len(machine.ports) {
+		machine.cpu.A = machine.ports[port]
+	}
 }
 
-func (game *Invaders) handleEvents() bool {
-	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-		switch e := event.(type) {
-
-		case *sdl.QuitEvent:
-			return false
-
-		case *sdl.KeyboardEvent:
-			key := e.Keysym.Scancode
-			if e.Type == sdl.KEYDOWN {
-				switch key {
-
-				case sdl.SCANCODE_ESCAPE:
-					return false
-				case sdl.SCANCODE_C: // INSERT COIN
-					game.ports[1] |= 0x01
-
-				case sdl.SCANCODE_S: // P1 START
-					game.ports[1] |= 1 << 2
-				case sdl.SCANCODE_RETURN: // P2 START
-					game.ports[1] |= 1 << 1
-
-				case sdl.SCANCODE_W: // P1 SHOOT
-					game.ports[1] |= 1 << 4
-				case sdl.SCANCODE_A: // P1 LEFT
-					game.ports[1] |= 1 << 5
-				case sdl.SCANCODE_D: // P1 RIGHT
-					game.ports[1] |= 1 << 6
-
-				case sdl.SCANCODE_UP: // P2 SHOOT
-					game.ports[2] |= 1 << 4
-				case sdl.SCANCODE_LEFT: // P2 LEFT
-					game.ports[2] |= 1 << 5
-				case sdl.SCANCODE_RIGHT: // P2 RIGHT
-					game.ports[2] |= 1 << 6
-				}
+// emulate a whole frame, every line is drawn when the beam reaches it so
+// video RAM changes during the frame show up like on the real raster
+func (machine *Machine) runFrame() {
+	cycles, lines := machine.config.CyclesPerFrame(), machine.config.TotalLines
+	for line := 0; line < lines; line++ {
+		switch line {
+		case MidScreenLine:
+			if machine.cpu.IntEnable {
+				machine.cpu.Interrupt(machine.profile.Interrupts.MidScreen)
 			}
-			if e.Type == sdl.KEYUP {
-				switch key {
-				case sdl.SCANCODE_C:
-					game.ports[1] &= ^uint8(1 << 0)
-
-				case sdl.SCANCODE_S:
-					game.ports[1] &= ^uint8(1 << 2)
-				case sdl.SCANCODE_RETURN:
-					game.ports[1] &= ^uint8(1 << 1)
-
-				case sdl.SCANCODE_W:
-					game.ports[1] &= ^uint8(1 << 4)
-				case sdl.SCANCODE_A:
-					game.ports[1] &= ^uint8(1 << 5)
-				case sdl.SCANCODE_D:
-					game.ports[1] &= ^uint8(1 << 6)
-
-				case sdl.SCANCODE_UP:
-					game.ports[2] &= ^uint8(1 << 4)
-				case sdl.SCANCODE_LEFT:
-					game.ports[2] &= ^uint8(1 << 5)
-				case sdl.SCANCODE_RIGHT:
-					game.ports[2] &= ^uint8(1 << 6)
-				}
+		case VisibleLines:
+			if machine.cpu.IntEnable {
+				machine.cpu.Interrupt(machine.profile.Interrupts.VBlank)
 			}
 		}
-	}
 
-	return true
-}
-
-func (game *Invaders) handleIn(port uint8) {
-	if port == 2 || port == 4 {
-		return
-	}
-
-	game.ports[port] = game.cpu.A
-}
+		if line < VisibleLines {
+			machine.drawLine(line)
+		}
 
-func (game *Invaders) handleOut(port uint8) {
-	if port == 3 {
-		return
+		start := machine.cpu.Cycles
+		machine.update(uint32((line + 1) * cycles / lines))
+		machine.sound.advance(machine.cpu.Cycles-start, uint64(machine.config.CPUClock))
 	}
 
-	game.cpu.A = game.ports[port]
+	// keep the cycles run past the end of the frame
+	machine.cpu.Cycles -= uint32(cycles)
 }
 
-func (game *Invaders) update() {
-	game.cpu.Cycles = 0
-	for game.cpu.Cycles < uint32(CyclesPerFrames/2) {
-		opcode := game.cpu.NextByte()
-
-		// emulate shift register
-		if opcode == 0xd3 {
-			nextByte := game.cpu.NextByte()
-			if nextByte == 2 {
-				game.shiftOffset = game.cpu.A
-			} else if nextByte == 4 {
-				game.shiftRegister = (uint16(game.cpu.A) << 8) | (uint16(game.shiftRegister) >> 8)
-			}
-		} else if opcode == 0xdb {
-			if game.cpu.NextByte() == 3 {
-				game.cpu.A = uint8(game.shiftRegister >> (8 - game.shiftOffset))
-			}
-		}
+// run the cpu until its cycle count reaches the target
+func (machine *Machine) update(cycles uint32) {
+	for machine.cpu.Cycles < cycles {
+		// looked at without counting a read, Step fetches it
+		opcode := machine.cpu.Memory[machine.cpu.PC]
 
-		game.cpu.Step(false)
+		machine.cpu.Step(false)
 
+		// the cpu leaves IN and OUT to the board with PC on the port number
 		if opcode == 0xd3 {
-			game.handleIn(game.cpu.NextByte())
-			game.cpu.PC++
+			machine.handleOut(machine.cpu.NextByte())
+			machine.cpu.PC++
 		}
 		if opcode == 0xdb {
-			game.handleOut(game.cpu.NextByte())
-			game.cpu.PC++
+			machine.handleIn(machine.cpu.NextByte())
+			machine.cpu.PC++
 		}
 	}
 }
 
-func (game *Invaders) draw() {
-	for i := 0; i < 256*224/8; i++ {
-		x := i * 8 % 256
-		y := i * 8 / 256
-
-		pix := game.cpu.Memory[cpu.VRAMOffset+i]
+// draw a line of video RAM, 32 bytes of 8 pixels each
+func (machine *Machine) drawLine(line int) {
+	vram := machine.cpu.Memory[cpu.VRAMOffset+line*32 : cpu.VRAMOffset+(line+1)*32]
 
+	for i, pix := range vram {
 		for b := 0; b < 8; b++ {
 			// rotate 90 deg
-			px := y
-			py := -(x + b) + int(ScreenHeight) - 1
-
-			index := (py*int(ScreenWidth) + px) * 4
-			if (pix>>b)&1 != 0 {
-				game.frameBuffer[index], game.frameBuffer[index+1], game.frameBuffer[index+2] = 0xff, 0xff, 0xff
-			} else {
-				game.frameBuffer[index], game.frameBuffer[index+1], game.frameBuffer[index+2] = 0x00, 0x00, 0x00
+			px := line
+			py := -(i*8 + b) + int(ScreenHeight) - 1
+
+			pixel := py*int(ScreenWidth) + px
+			lit := (pix>>b)&1 != 0
+
+			switch {
+			case machine.phosphor != nil:
+				tint, level := machine.tint[pixel], machine.phosphor.expose(pixel, lit)
+				machine.frameBuffer.SetRGBA(px, py, color.RGBA{
+					uint8(float32(tint.R) * level), uint8(float32(tint.G) * level), uint8(float32(tint.B) * level), 0xff,
+				})
+			case lit:
+				machine.frameBuffer.SetRGBA(px, py, machine.tint[pixel])
+			default:
+				machine.frameBuffer.SetRGBA(px, py, color.RGBA{0x00, 0x00, 0x00, 0xff})
 			}
 		}
 	}
-
-	game.updateScreen()
 }
@@ -0,0 +1,93 @@
+package invaders
+
+import "testing"
+
+// machine running the program from address 0 for a frame, interrupts stay
+// disabled as the program never enables them
+func runProgram(t *testing.T, controls Controls, program ...byte) *Machine {
+	t.Helper()
+	machine, err := NewMachine(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range program {
+		machine.Poke(uint16(i), b)
+	}
+	machine.StepFrame(controls)
+	return machine
+}
+
+// program storing A to 0x2000 and looping there, appended to the others
+func storeA(program []byte) []byte {
+	end := uint16(len(program) + 3)
+	return append(program, 0x32, 0x00, 0x20, 0xc3, byte(end), byte(end>>8))
+}
+
+func TestShiftRegister(t *testing.T) {
+	tests := []struct {
+		data   []uint8
+		amount uint8
+		want   uint8
+	}{
+		{[]uint8{0xab, 0xcd}, 0, 0xcd},
+		{[]uint8{0xab, 0xcd}, 3, 0x6d},
+		{[]uint8{0xab, 0xcd}, 7, 0xd5},
+		{[]uint8{0xab, 0xcd}, 8 + 3, 0x6d}, // 3 bits wide
+		{[]uint8{0xff}, 4, 0xf0},
+		{[]uint8{0x11, 0x22, 0x33}, 4, 0x32},
+	}
+
+	for _, test := range tests {
+		var program []byte
+		for _, data := range test.data {
+			program = append(program, 0x3e, data, 0xd3, 0x04) // MVI A; OUT 4
+		}
+		program = append(program, 0x3e, test.amount, 0xd3, 0x02) // MVI A; OUT 2
+		program = append(program, 0xdb, 0x03)                    // IN 3
+
+		machine := runProgram(t, 0, storeA(program)...)
+		if got := machine.Peek(0x2000); got != test.want {
+			t.Errorf("% x shifted by %d: got %02x, want %02x", test.data, test.amount, got, test.want)
+		}
+	}
+}
+
+func TestInputPorts(t *testing.T) {
+	var coin Controls
+	coin.Set(Coin, true)
+
+	tests := []struct {
+		name     string
+		port     uint8
+		controls Controls
+		want     uint8
+	}{
+		{"port 0", 0, 0, port0Default},
+		{"port 1 idle", 1, 0, 0x00},
+		{"port 1 coin", 1, coin, 0x01},
+		{"unconnected", 0x80, 0, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// A is set first so a missed IN shows
+			machine := runProgram(t, test.controls, storeA([]byte{0x3e, 0x5a, 0xdb, test.port})...)
+			if got := machine.Peek(0x2000); got != test.want {
+				t.Errorf("IN %d: got %02x, want %02x", test.port, got, test.want)
+			}
+		})
+	}
+}
+
+func TestOutputPorts(t *testing.T) {
+	// the OUT to an unconnected port is ignored, the next one still runs
+	program := storeA([]byte{0x3e, 0x21, 0xd3, 0x80, 0x3e, 0x42, 0xd3, 0x06, 0x3e, 0x42})
+	machine := runProgram(t, 0, program...)
+
+	if got := machine.Peek(0x2000); got != 0x42 {
+		t.Errorf("program stopped, got %02x", got)
+	}
+	if machine.outputs[6] != 0x42 {
+		t.Errorf("OUT 6: got %02x, want 42", machine.outputs[6])
+	}
+}
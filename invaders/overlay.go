@@ -0,0 +1,102 @@
+package invaders
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+)
+
+// Overlay is a strip of coloured gel over a region of the screen, in screen
+// coordinates after rotation
+type Overlay struct {
+	Rect  image.Rectangle
+	Color color.RGBA
+}
+
+// Gel colours
+var (
+	overlayRed   = color.RGBA{0xff, 0x20, 0x20, 0xff}
+	overlayGreen = color.RGBA{0x20, 0xff, 0x20, 0xff}
+)
+
+// Standard Space Invaders cabinet, red over the UFO and green over the
+// shields, the player and the ships remaining
+var invadersOverlays = []Overlay{
+	{image.Rect(0, 32, 224, 64), overlayRed},
+	{image.Rect(0, 184, 224, 240), overlayGreen},
+	{image.Rect(16, 240, 134, 256), overlayGreen},
+}
+
+// SetOverlays replaces the colour overlays, nil turns them off
+func (machine *Machine) SetOverlays(overlays []Overlay) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	for i := range machine.tint {
+		machine.tint[i] = white
+	}
+
+	// later overlays are laid on top of the previous ones
+	for _, overlay := range overlays {
+		r := overlay.Rect.Intersect(image.Rect(0, 0, int(ScreenWidth), int(ScreenHeight)))
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				machine.tint[y*int(ScreenWidth)+x] = overlay.Color
+			}
+		}
+	}
+}
+
+// LoadOverlays reads overlay definitions from a file
+func LoadOverlays(path string) ([]Overlay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	overlays, err := ParseOverlays(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return overlays, nil
+}
+
+// ParseOverlays reads overlay definitions, one per line as
+//
+//	x y width height #rrggbb
+//
+// blank lines and lines starting with # are ignored
+func ParseOverlays(r io.Reader) ([]Overlay, error) {
+	var overlays []Overlay
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if len(strings.Fields(text)) != 5 {
+			return nil, fmt.Errorf("line %d: expected x y width height #rrggbb", line)
+		}
+
+		var (
+			x, y, w, h int
+			c          color.RGBA
+		)
+		if _, err := fmt.Sscanf(text, "%d %d %d %d #%02x%02x%02x", &x, &y, &w, &h, &c.R, &c.G, &c.B); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if w <= 0 || h <= 0 {
+			return nil, fmt.Errorf("line %d: empty overlay", line)
+		}
+		c.A = 0xff
+
+		overlays = append(overlays, Overlay{image.Rect(x, y, x+w, y+h), c})
+	}
+
+	return overlays, scanner.Err()
+}
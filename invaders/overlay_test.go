@@ -0,0 +1,56 @@
+package invaders
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOverlays(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		overlays []Overlay
+		err      string
+	}{
+		{"empty", "", nil, ""},
+		{"comments and blank lines", "# x y width height #rrggbb\n\n  \n", nil, ""},
+		{
+			"overlays",
+			"0 32 224 32 #ff2020\n  16 240 118 16   #20FF20\n-8 -8 16 16 #000000\n",
+			[]Overlay{
+				{image.Rect(0, 32, 224, 64), color.RGBA{0xff, 0x20, 0x20, 0xff}},
+				{image.Rect(16, 240, 134, 256), color.RGBA{0x20, 0xff, 0x20, 0xff}},
+				{image.Rect(-8, -8, 8, 8), color.RGBA{0, 0, 0, 0xff}},
+			},
+			"",
+		},
+		{"missing colour", "0 32 224 32", nil, "line 1: expected x y width height #rrggbb"},
+		{"extra field", "# red\n0 32 224 32 #ff2020 blue", nil, "line 2: expected x y width height #rrggbb"},
+		{"not a number", "0 top 224 32 #ff2020", nil, "line 1: expected integer"},
+		{"colour without #", "0 32 224 32 ff2020", nil, "line 1: input does not match format"},
+		{"short colour", "0 32 224 32 #ff20", nil, "line 1: EOF"},
+		{"empty width", "0 32 0 32 #ff2020", nil, "line 1: empty overlay"},
+		{"negative height", "0 32 224 -1 #ff2020", nil, "line 1: empty overlay"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			overlays, err := ParseOverlays(strings.NewReader(test.text))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(overlays, test.overlays) {
+				t.Errorf("got %v, want %v", overlays, test.overlays)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package invaders
+
+import (
+	"math"
+	"time"
+)
+
+// phosphor persistence of the monitor, a lit pixel keeps glowing after the
+// beam has moved on and fades by half every half-life
+type phosphor struct {
+	halfLife time.Duration
+
+	// intensity kept from one frame to the next
+	decay     float32
+	intensity []float32
+}
+
+// SetPhosphorHalfLife sets how long the monitor phosphor takes to fade to
+// half its brightness, zero turns the persistence off
+func (machine *Machine) SetPhosphorHalfLife(halfLife time.Duration) {
+	if halfLife <= 0 {
+		machine.phosphor = nil
+		return
+	}
+
+	frames := halfLife.Seconds() * machine.config.FrameRate()
+	machine.phosphor = &phosphor{
+		halfLife:  halfLife,
+		decay:     float32(math.Pow(0.5, 1/frames)),
+		intensity: make([]float32, ScreenWidth*ScreenHeight),
+	}
+}
+
+// PhosphorHalfLife of the monitor, zero without persistence
+func (machine *Machine) PhosphorHalfLife() time.Duration {
+	if machine.phosphor == nil {
+		return 0
+	}
+	return machine.phosphor.halfLife
+}
+
+// expose the pixel to the beam for a frame and return its brightness
+func (p *phosphor) expose(pixel int, lit bool) float32 {
+	level := p.intensity[pixel] * p.decay
+	if lit {
+		level = 1
+	}
+	p.intensity[pixel] = level
+
+	return level
+}
@@ -0,0 +1,127 @@
+package invaders
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/protoshark/invaders8080/cpu"
+)
+
+// Profile describes one of the games running on the Midway 8080 board
+type Profile struct {
+	Name        string
+	Description string
+
+	// ROM map, every chip with its checksums
+	ROMs []ROMChip
+
+	Ports      PortWiring
+	Interrupts Interrupts
+	Video      VideoQuirks
+	// scores in RAM, nil if unknown
+	Scores *ScoreLayout
+
+	// voices of the sound board on the OUT ports
+	sound soundBoard
+}
+
+// PortWiring is how the board is wired to the cpu IN/OUT ports
+type PortWiring struct {
+	// controls on the input ports
+	Inputs map[Input][]PortBit
+	// value of the input ports 0-2 with nothing pressed, DIP switches included
+	Defaults [3]uint8
+
+	// MB14241 shift register
+	ShiftAmount uint8 // OUT
+	ShiftData   uint8 // OUT
+	ShiftResult uint8 // IN
+
+	// screen flip of the cocktail cabinet, a line on an OUT port
+	Flip PortBit
+}
+
+// PortBit is a single line on one of the ports
+type PortBit struct {
+	Port uint8
+	Mask uint8
+}
+
+// Interrupts are the RST vectors fired during the frame
+type Interrupts struct {
+	MidScreen uint16
+	VBlank    uint16
+}
+
+// VideoQuirks of the board
+type VideoQuirks struct {
+	// cellophane strips on the cabinet monitor
+	Overlays []Overlay
+}
+
+// Wiring of the original Space Invaders board
+var invadersPorts = PortWiring{
+	Inputs:   invadersInputs,
+	Defaults: [3]uint8{port0Default, 0x00, 0x00},
+
+	ShiftAmount: 2,
+	ShiftData:   4,
+	ShiftResult: 3,
+
+	Flip: PortBit{Port: 5, Mask: 1 << 5},
+}
+
+// RST 1 and RST 2
+var invadersInterrupts = Interrupts{
+	MidScreen: 0x08,
+	VBlank:    0x10,
+}
+
+// Known games, indexed by name. The clones and sequels of the board are not
+// supported: their sets, inputs, DIP switches and sound wiring have not been
+// verified against dumps
+var profiles = map[string]*Profile{
+	"invaders": {
+		Name:        "invaders",
+		Description: "Space Invaders (Midway, 1978)",
+		ROMs:        invadersROMs,
+		Ports:       invadersPorts,
+		Interrupts:  invadersInterrupts,
+		Video:       VideoQuirks{Overlays: invadersOverlays},
+		Scores:      invadersScores,
+		sound:       invadersSound,
+	},
+}
+
+// DefaultProfile is the original Space Invaders
+var DefaultProfile = profiles["invaders"]
+
+// LookupProfile finds a game by name
+func LookupProfile(name string) (*Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown game %q", name)
+	}
+	return profile, nil
+}
+
+// Profiles lists the known games sorted by name
+func Profiles() []*Profile {
+	list := make([]*Profile, 0, len(profiles))
+	for _, profile := range profiles {
+		list = append(list, profile)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// memory map of the profile, ROM chips are write protected
+func (profile *Profile) memoryMap() (rom, ram []cpu.Region) {
+	for _, chip := range profile.ROMs {
+		rom = append(rom, cpu.Region{Start: chip.Offset, End: chip.Offset + uint16(chip.Size-1)})
+	}
+
+	ram = []cpu.Region{{Start: cpu.RomOffset, End: cpu.RAMEnd}}
+	return rom, ram
+}
@@ -0,0 +1,353 @@
+package invaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/protoshark/invaders8080/patch"
+)
+
+// ROMChip is one of the EPROMs on the board
+type ROMChip struct {
+	Name   string
+	Offset uint16
+	Size   int
+	CRC32  uint32
+	SHA1   string
+}
+
+// Space Invaders ROM set, four 2 KB chips
+var invadersROMs = []ROMChip{
+	{"invaders.h", 0x0000, 0x0800, 0x734f5ad8, "ff6200af4c9110d8181249cbcef1a8a40fa40b7b"},
+	{"invaders.g", 0x0800, 0x0800, 0x6bfaca4a, "16f48649b531bdef8c2d1446c429b5f414524350"},
+	{"invaders.f", 0x1000, 0x0800, 0x0ccead96, "537aef03468f63c5b9e11dd61e253f7ae17d9743"},
+	{"invaders.e", 0x1800, 0x0800, 0x14e538b0, "1d6ca0c99f9df71e2990b610deb9d7da0125e2d8"},
+}
+
+// room left in a ROM archive for its headers and the files beside the chips
+const archiveSlack = 64 << 10
+
+// LoadROM loads the ROM set from a zip archive or a combined image, every
+// chip is verified before anything is written to memory.
+func (machine *Machine) LoadROM(r io.Reader) error {
+	limit := maxROMInput(machine.profile.ROMs)
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > limit {
+		return fmt.Errorf("ROM: larger than %d bytes", limit)
+	}
+
+	dumps, err := readROMData("ROM", data, machine.profile.ROMs)
+	if err != nil {
+		return err
+	}
+	return machine.installROMs(dumps)
+}
+
+// LoadROMFiles loads the ROM set into memory. It accepts a directory holding
+// the chip files, a zip archive, the chip files themselves or a single
+// combined image, every chip is verified before anything is written to
+// memory.
+func (machine *Machine) LoadROMFiles(paths ...string) error {
+	chips := machine.profile.ROMs
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no ROM given")
+	}
+
+	var (
+		dumps map[string][]byte
+		err   error
+	)
+	if len(paths) == 1 {
+		dumps, err = readROMSource(paths[0], chips)
+	} else {
+		dumps, err = readChipFiles(paths, chips)
+	}
+	if err != nil {
+		return err
+	}
+	return machine.installROMs(dumps)
+}
+
+// verify the chips and copy them into memory
+func (machine *Machine) installROMs(dumps map[string][]byte) error {
+	chips := machine.profile.ROMs
+	if err := verifyROMs(chips, dumps); err != nil {
+		return err
+	}
+
+	for _, chip := range chips {
+		copy(machine.cpu.Memory[chip.Offset:], dumps[chip.Name])
+	}
+	machine.hashROM()
+
+	return nil
+}
+
+// ROMHash is the SHA-1 of the chips loaded and patched, in the order of the
+// profile, empty before the ROM is loaded
+func (machine *Machine) ROMHash() string {
+	return machine.romHash
+}
+
+func (machine *Machine) hashROM() {
+	sum := sha1.Sum(machine.romImage())
+	machine.romHash = hex.EncodeToString(sum[:])
+}
+
+// the chips in memory concatenated, as a combined image
+func (machine *Machine) romImage() []byte {
+	var image []byte
+	for _, chip := range machine.profile.ROMs {
+		image = append(image, machine.cpu.Memory[chip.Offset:int(chip.Offset)+chip.Size]...)
+	}
+	return image
+}
+
+// ApplyPatch applies an IPS or BPS patch to the ROM loaded, taken as a
+// combined image. The patched image must keep the size of the set
+func (machine *Machine) ApplyPatch(data []byte) error {
+	image := machine.romImage()
+	patched, err := patch.Apply(image, data)
+	if err != nil {
+		return err
+	}
+	if len(patched) != len(image) {
+		return fmt.Errorf("patched ROM is %d bytes, expected %d", len(patched), len(image))
+	}
+
+	start := 0
+	for _, chip := range machine.profile.ROMs {
+		copy(machine.cpu.Memory[chip.Offset:], patched[start:start+chip.Size])
+		start += chip.Size
+	}
+	machine.hashROM()
+
+	return nil
+}
+
+// ApplyPatchFile applies the IPS or BPS patch in a file to the ROM loaded
+func (machine *Machine) ApplyPatchFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := machine.ApplyPatch(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// read the chips from a directory, a zip archive or a single file
+func readROMSource(path string, chips []ROMChip) (map[string][]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return readROMDir(path, chips)
+	}
+	if limit := maxROMInput(chips); info.Size() > int64(limit) {
+		return nil, fmt.Errorf("%s: larger than %d bytes", path, limit)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// a single chip
+	name := strings.ToLower(filepath.Base(path))
+	for _, chip := range chips {
+		if chip.Name == name && !isZip(data) {
+			return map[string][]byte{name: data}, nil
+		}
+	}
+
+	return readROMData(path, data, chips)
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// read the chips from a zip archive or a combined image
+func readROMData(path string, data []byte, chips []ROMChip) (map[string][]byte, error) {
+	if isZip(data) {
+		return readROMZip(path, data, chips)
+	}
+
+	// otherwise it must be every chip concatenated
+	if len(data) != romSetSize(chips) {
+		return nil, fmt.Errorf("%s: unexpected size %d bytes, a combined image is %d bytes",
+			path, len(data), romSetSize(chips))
+	}
+
+	dumps := make(map[string][]byte, len(chips))
+	start := 0
+	for _, chip := range chips {
+		dumps[chip.Name] = data[start : start+chip.Size]
+		start += chip.Size
+	}
+
+	return dumps, nil
+}
+
+// read the chips found by name in a directory
+func readROMDir(dir string, chips []ROMChip) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dumps := make(map[string][]byte)
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		for _, chip := range chips {
+			if chip.Name != name {
+				continue
+			}
+
+			data, err := readChipFile(filepath.Join(dir, entry.Name()), chip)
+			if err != nil {
+				return nil, err
+			}
+			dumps[name] = data
+		}
+	}
+
+	return dumps, nil
+}
+
+// read the chips found by name in a zip archive, the other files are skipped
+func readROMZip(path string, data []byte, chips []ROMChip) (map[string][]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	sizes := make(map[string]int, len(chips))
+	for _, chip := range chips {
+		sizes[chip.Name] = chip.Size
+	}
+
+	dumps := make(map[string][]byte)
+	for _, file := range archive.File {
+		name := strings.ToLower(filepath.Base(file.Name))
+		size, ok := sizes[name]
+		if file.FileInfo().IsDir() || !ok {
+			continue
+		}
+
+		// the sizes in the archive are not trusted, a chip too large is
+		// cut one byte past its size for verifyROMs to reject
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, file.Name, err)
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(r, int64(size)+1))
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, file.Name, err)
+		}
+
+		dumps[name] = content
+	}
+
+	return dumps, nil
+}
+
+// read individual chip files, named after the chip they hold
+func readChipFiles(paths []string, chips []ROMChip) (map[string][]byte, error) {
+	dumps := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		name := strings.ToLower(filepath.Base(path))
+		for _, chip := range chips {
+			if chip.Name != name {
+				continue
+			}
+
+			data, err := readChipFile(path, chip)
+			if err != nil {
+				return nil, err
+			}
+			dumps[name] = data
+		}
+	}
+
+	return dumps, nil
+}
+
+// read the file of a chip, refusing one larger than the chip
+func readChipFile(path string, chip ROMChip) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > int64(chip.Size) {
+		return nil, fmt.Errorf("%s: bad dump, size is %d bytes, expected %d", chip.Name, info.Size(), chip.Size)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// check every chip is present, has the right size and matches its checksums
+func verifyROMs(chips []ROMChip, dumps map[string][]byte) error {
+	var missing []string
+	for _, chip := range chips {
+		if _, ok := dumps[chip.Name]; !ok {
+			missing = append(missing, chip.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing ROM: %s", strings.Join(missing, ", "))
+	}
+
+	for _, chip := range chips {
+		data := dumps[chip.Name]
+
+		if len(data) != chip.Size {
+			return fmt.Errorf("%s: bad dump, size is %d bytes, expected %d", chip.Name, len(data), chip.Size)
+		}
+
+		if sum := crc32.ChecksumIEEE(data); sum != chip.CRC32 {
+			return fmt.Errorf("%s: bad dump, crc32 is %08x, expected %08x", chip.Name, sum, chip.CRC32)
+		}
+
+		sum := sha1.Sum(data)
+		if hex.EncodeToString(sum[:]) != chip.SHA1 {
+			return fmt.Errorf("%s: bad dump, sha1 is %x, expected %s", chip.Name, sum, chip.SHA1)
+		}
+	}
+
+	return nil
+}
+
+// size of the whole set
+// largest input holding the set, a combined image or an archive
+func maxROMInput(chips []ROMChip) int {
+	return romSetSize(chips) + archiveSlack
+}
+
+func romSetSize(chips []ROMChip) int {
+	size := 0
+	for _, chip := range chips {
+		size += chip.Size
+	}
+	return size
+}
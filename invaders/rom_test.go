@@ -0,0 +1,208 @@
+package invaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chips of a made up set, with the checksums of their content
+func testChips() ([]ROMChip, map[string][]byte) {
+	dumps := map[string][]byte{
+		"test.a": bytes.Repeat([]byte{0xaa}, 8),
+		"test.b": bytes.Repeat([]byte{0xbb}, 4),
+	}
+
+	var chips []ROMChip
+	for _, chip := range []ROMChip{{Name: "test.a", Offset: 0, Size: 8}, {Name: "test.b", Offset: 8, Size: 4}} {
+		data := dumps[chip.Name]
+		sum := sha1.Sum(data)
+		chip.CRC32, chip.SHA1 = crc32.ChecksumIEEE(data), hex.EncodeToString(sum[:])
+		chips = append(chips, chip)
+	}
+	return chips, dumps
+}
+
+func TestVerifyROMs(t *testing.T) {
+	chips, good := testChips()
+
+	with := func(name string, data []byte) map[string][]byte {
+		dumps := map[string][]byte{}
+		for n, d := range good {
+			dumps[n] = d
+		}
+		if data == nil {
+			delete(dumps, name)
+		} else {
+			dumps[name] = data
+		}
+		return dumps
+	}
+	badSHA1 := append([]ROMChip(nil), chips...)
+	badSHA1[1].SHA1 = strings.Repeat("0", 40)
+
+	tests := []struct {
+		name  string
+		chips []ROMChip
+		dumps map[string][]byte
+		err   string
+	}{
+		{"good", chips, good, ""},
+		{"extra files", chips, with("readme.txt", []byte("hi")), ""},
+		{"missing", chips, with("test.b", nil), "missing ROM: test.b"},
+		{"all missing", chips, map[string][]byte{}, "missing ROM: test.a, test.b"},
+		{"size", chips, with("test.a", make([]byte, 7)), "test.a: bad dump, size is 7 bytes, expected 8"},
+		{"crc32", chips, with("test.b", []byte{0xbb, 0xbb, 0xbb, 0xba}), "test.b: bad dump, crc32 is "},
+		{"sha1", badSHA1, good, "test.b: bad dump, sha1 is "},
+		{"no checksums", []ROMChip{{Name: "test.b", Size: 4}}, good, "test.b: bad dump, crc32 is "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyROMs(test.chips, test.dumps)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.err != "" && (err == nil || !strings.HasPrefix(err.Error(), test.err)):
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestReadROMData(t *testing.T) {
+	chips, good := testChips()
+	combined := append(append([]byte(nil), good["test.a"]...), good["test.b"]...)
+
+	zipped := func(files map[string][]byte) []byte {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for name, data := range files {
+			f, err := w.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(data)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		dumps map[string][]byte
+		err   string
+	}{
+		{"combined", combined, good, ""},
+		{"combined too short", combined[:11], nil, "set.rom: unexpected size 11 bytes, a combined image is 12 bytes"},
+		{"zip", zipped(good), good, ""},
+		{
+			"zip with other files",
+			zipped(map[string][]byte{"test.a": good["test.a"], "test.b": good["test.b"], "readme.txt": make([]byte, 100)}),
+			good, "",
+		},
+		{
+			"zip with a chip too large",
+			zipped(map[string][]byte{"test.a": make([]byte, 100), "test.b": good["test.b"]}),
+			map[string][]byte{"test.a": make([]byte, 9), "test.b": good["test.b"]}, "",
+		},
+		{
+			"zip in a directory, upper case",
+			zipped(map[string][]byte{"set/TEST.A": good["test.a"], "set/test.b": good["test.b"]}),
+			good, "",
+		},
+		{"broken zip", []byte("PK\x03\x04broken"), nil, "set.rom: zip: not a valid zip file"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dumps, err := readROMData("set.rom", test.data, chips)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(dumps, test.dumps) {
+				t.Errorf("got %v, want %v", dumps, test.dumps)
+			}
+		})
+	}
+}
+
+func TestLoadROMLimits(t *testing.T) {
+	machine, err := NewMachine(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := maxROMInput(invadersROMs)
+
+	tests := []struct {
+		name string
+		size int
+		err  string
+	}{
+		{"at the limit", limit, fmt.Sprintf("ROM: unexpected size %d bytes, a combined image is 8192 bytes", limit)},
+		{"past the limit", limit + 1, fmt.Sprintf("ROM: larger than %d bytes", limit)},
+		{"far past the limit", 10 * limit, fmt.Sprintf("ROM: larger than %d bytes", limit)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := machine.LoadROM(bytes.NewReader(make([]byte, test.size)))
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestReadChipFiles(t *testing.T) {
+	chips, good := testChips()
+	dir, err := ioutil.TempDir("", "roms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string][]byte{"TEST.A": good["test.a"], "test.b": make([]byte, 5), "other.bin": make([]byte, 100)}
+	var paths []string
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	_, err = readChipFiles(paths, chips)
+	if want := "test.b: bad dump, size is 5 bytes, expected 4"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "test.b"), good["test.b"], 0644); err != nil {
+		t.Fatal(err)
+	}
+	dumps, err := readChipFiles(paths, chips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dumps, good) {
+		t.Errorf("got %v, want %v", dumps, good)
+	}
+}
@@ -0,0 +1,69 @@
+package invaders
+
+import (
+	"fmt"
+
+	"github.com/protoshark/invaders8080/cpu"
+)
+
+// Snapshot of the machine state, to go back to with Restore
+type Snapshot struct {
+	profile *Profile
+
+	cpu    cpu.CPU
+	memory []byte
+
+	ports, outputs [9]uint8
+	shiftOffset    uint8
+	shiftRegister  uint16
+	flipped        bool
+	playing        bool
+
+	sound    sound
+	phosphor []float32
+}
+
+// Snapshot the machine state
+func (machine *Machine) Snapshot() *Snapshot {
+	s := &Snapshot{
+		profile:       machine.profile,
+		cpu:           machine.cpu,
+		memory:        append([]byte(nil), machine.cpu.Memory...),
+		ports:         machine.ports,
+		outputs:       machine.outputs,
+		shiftOffset:   machine.shiftOffset,
+		shiftRegister: machine.shiftRegister,
+		flipped:       machine.flipped,
+		playing:       machine.playing,
+		sound:         machine.sound.clone(),
+	}
+	if machine.phosphor != nil {
+		s.phosphor = append([]float32(nil), machine.phosphor.intensity...)
+	}
+	return s
+}
+
+// Restore the machine to a snapshot taken of the same game
+func (machine *Machine) Restore(s *Snapshot) error {
+	if s.profile != machine.profile {
+		return fmt.Errorf("snapshot of %s, not %s", s.profile.Name, machine.profile.Name)
+	}
+
+	memory := machine.cpu.Memory
+	copy(memory, s.memory)
+	machine.cpu = s.cpu
+	machine.cpu.Memory = memory
+	machine.installWatch()
+
+	machine.ports, machine.outputs = s.ports, s.outputs
+	machine.shiftOffset, machine.shiftRegister = s.shiftOffset, s.shiftRegister
+	machine.flipped, machine.playing = s.flipped, s.playing
+
+	restored := s.sound.clone()
+	machine.sound = &restored
+	if machine.phosphor != nil && s.phosphor != nil {
+		copy(machine.phosphor.intensity, s.phosphor)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package invaders
+
+import (
+	"math"
+)
+
+// SampleRate of the audio output, 16-bit mono
+const SampleRate = 44100
+
+// voice of the sound board, a square wave or noise with a decaying level
+type voice struct {
+	freq   float64 // Hz, zero for noise
+	warble float64 // rate of the frequency modulation in Hz
+	depth  float64 // of the modulation, fraction of freq
+	level  float64
+	decay  float64 // level kept after a second
+	held   bool    // plays for as long as its port bit is set
+
+	playing bool
+	gain    float64
+	phase   float64
+	time    float64
+}
+
+// sound trigger wired to an OUT port bit
+type soundTrigger struct {
+	port  uint8
+	mask  uint8
+	voice voice
+}
+
+// sound board of a game, its voices and the amplifier enable line
+type soundBoard struct {
+	triggers []soundTrigger
+	enable   PortBit
+}
+
+// Space Invaders sound board, the discrete circuits are approximated with
+// simple square and noise voices
+var invadersSound = soundBoard{
+	triggers: []soundTrigger{
+		{3, 1 << 0, voice{freq: 500, warble: 7, depth: 0.25, level: 0.3, decay: 1, held: true}}, // UFO
+		{3, 1 << 1, voice{level: 0.5, decay: 0.001}},                                            // shot
+		{3, 1 << 2, voice{level: 0.7, decay: 0.02}},                                             // player dies
+		{3, 1 << 3, voice{level: 0.5, decay: 0.0001}},                                           // invader dies
+		{3, 1 << 4, voice{freq: 1200, warble: 10, depth: 0.1, level: 0.3, decay: 0.01}},         // extra ship
+		{5, 1 << 0, voice{freq: 87.3, level: 0.6, decay: 0.0001}},                               // fleet 1
+		{5, 1 << 1, voice{freq: 77.8, level: 0.6, decay: 0.0001}},                               // fleet 2
+		{5, 1 << 2, voice{freq: 69.3, level: 0.6, decay: 0.0001}},                               // fleet 3
+		{5, 1 << 3, voice{freq: 65.4, level: 0.6, decay: 0.0001}},                               // fleet 4
+		{5, 1 << 4, voice{freq: 300, warble: 12, depth: 0.5, level: 0.4, decay: 0.05}},          // UFO hit
+	},
+	enable: PortBit{Port: 3, Mask: 1 << 5}, // amplifier
+}
+
+// sound board state, samples are generated as the cpu runs so they stay in
+// step with the emulated cycles
+type sound struct {
+	triggers []soundTrigger
+	enable   PortBit
+	outputs  [256]uint8 // last value written to every port
+	noise    uint16     // LFSR
+
+	cycles  uint64 // cycles run
+	samples uint64 // samples generated
+	buffer  []int16
+}
+
+func newSound(board soundBoard) *sound {
+	s := &sound{enable: board.enable, noise: 0xace1}
+	s.triggers = append(s.triggers, board.triggers...)
+	return s
+}
+
+// copy of the sound board state, pending samples are left out
+func (s *sound) clone() sound {
+	c := *s
+	c.triggers = append([]soundTrigger(nil), s.triggers...)
+	c.buffer = nil
+	return c
+}
+
+// port write, voices start on the rising edge of their bit
+func (s *sound) write(port uint8, value uint8) {
+	last := s.outputs[port]
+	s.outputs[port] = value
+
+	for i := range s.triggers {
+		t := &s.triggers[i]
+		if t.port != port {
+			continue
+		}
+
+		rising := value&t.mask != 0 && last&t.mask == 0
+		falling := value&t.mask == 0 && last&t.mask != 0
+		switch {
+		case rising:
+			t.voice.playing, t.voice.gain, t.voice.phase, t.voice.time = true, t.voice.level, 0, 0
+		case falling && t.voice.held:
+			t.voice.playing = false
+		}
+	}
+}
+
+// advance the sound board by some cpu cycles, clock is the cpu clock in Hz
+func (s *sound) advance(cycles uint32, clock uint64) {
+	s.cycles += uint64(cycles)
+
+	for target := s.cycles * SampleRate / clock; s.samples < target; s.samples++ {
+		s.buffer = append(s.buffer, s.sample())
+	}
+}
+
+// take the samples generated since the last call
+func (s *sound) drain() []int16 {
+	samples := s.buffer
+	s.buffer = nil
+	return samples
+}
+
+// next sample of the mix
+func (s *sound) sample() int16 {
+	// the noise source runs whether heard or not
+	s.noise = (s.noise >> 1) ^ (-(s.noise & 1) & 0xb400)
+	noise := float64(s.noise&1)*2 - 1
+
+	if s.outputs[s.enable.Port]&s.enable.Mask == 0 {
+		return 0
+	}
+
+	const dt = 1. / SampleRate
+	mix := 0.
+	for i := range s.triggers {
+		v := &s.triggers[i].voice
+		if !v.playing {
+			continue
+		}
+
+		if v.freq == 0 {
+			mix += noise * v.gain
+		} else {
+			freq := v.freq * (1 + v.depth*math.Sin(2*math.Pi*v.warble*v.time))
+			v.phase = math.Mod(v.phase+freq*dt, 1)
+			if v.phase < 0.5 {
+				mix += v.gain
+			} else {
+				mix -= v.gain
+			}
+		}
+
+		v.time += dt
+		v.gain *= math.Pow(v.decay, dt)
+		if v.gain < 0.001 {
+			v.playing = false
+		}
+	}
+
+	return int16(math.Max(-1, math.Min(1, mix/2)) * math.MaxInt16)
+}
@@ -0,0 +1,38 @@
+package invaders
+
+import "testing"
+
+func TestSoundEnable(t *testing.T) {
+	tests := []struct {
+		name  string
+		value uint8
+		heard bool
+	}{
+		{"silent", 0x00, false},
+		{"amplifier off", 0x02, false},
+		{"amplifier alone", 0x20, false},
+		{"shot", 0x22, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			machine, err := NewMachine(DefaultConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// MVI A; OUT 3; JMP to itself
+			for i, b := range []byte{0x3e, test.value, 0xd3, 0x03, 0xc3, 0x04, 0x00} {
+				machine.Poke(uint16(i), b)
+			}
+
+			_, samples := machine.StepFrame(0)
+			heard := false
+			for _, sample := range samples {
+				heard = heard || sample != 0
+			}
+			if heard != test.heard {
+				t.Errorf("OUT 3 %02x: heard %v, want %v", test.value, heard, test.heard)
+			}
+		})
+	}
+}
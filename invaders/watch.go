@@ -0,0 +1,32 @@
+package invaders
+
+// MemoryAccess counts the reads and writes of every address by the cpu,
+// instruction fetches included. The counts add up until the caller clears
+// them
+type MemoryAccess struct {
+	Reads  [0x10000]uint32
+	Writes [0x10000]uint32
+}
+
+// Clear the counts
+func (access *MemoryAccess) Clear() {
+	*access = MemoryAccess{}
+}
+
+// WatchMemory counts the memory accesses into access, nil stops counting
+func (machine *Machine) WatchMemory(access *MemoryAccess) {
+	machine.access = access
+	machine.installWatch()
+}
+
+// hook the counters into the cpu, again whenever it is replaced
+func (machine *Machine) installWatch() {
+	access := machine.access
+	if access == nil {
+		machine.cpu.OnRead, machine.cpu.OnWrite = nil, nil
+		return
+	}
+
+	machine.cpu.OnRead = func(addr uint16) { access.Reads[addr]++ }
+	machine.cpu.OnWrite = func(addr uint16) { access.Writes[addr]++ }
+}
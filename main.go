@@ -1,16 +1,276 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/protoshark/invaders8080/cheat"
+	"github.com/protoshark/invaders8080/filter"
+	"github.com/protoshark/invaders8080/frontend"
+	"github.com/protoshark/invaders8080/hiscore"
 	"github.com/protoshark/invaders8080/invaders"
+	"github.com/protoshark/invaders8080/patch"
 )
 
 func main() {
-	args := os.Args[1:]
+	gameName := flag.String("game", invaders.DefaultProfile.Name, "game to run, see -list")
+	list := flag.Bool("list", false, "list the supported games")
+	overlay := flag.String("overlay", "default", "colour overlay: default, none or an overlay file")
+	layout := flag.String("layout", "", "cabinet artwork layout file")
+	scale := flag.String("scale", "aspect", "window scaling: aspect, integer or fill")
+	linear := flag.Bool("linear", false, "linear filtering when scaling instead of nearest")
+	fullscreen := flag.Bool("fullscreen", false, "start in fullscreen")
+	vsync := flag.Bool("vsync", false, "present in step with the monitor refresh")
+	cpuClock := flag.Int("cpu-clock", invaders.DefaultConfig.CPUClock, "cpu clock in Hz")
+	refresh := flag.Float64("refresh", invaders.DefaultConfig.RefreshRate, "monitor refresh rate in Hz")
+	lines := flag.Int("lines", invaders.DefaultConfig.TotalLines, "scanlines per frame")
+	speed := flag.Int("speed", 100, "emulation speed in percent, 50 to 400")
+	cocktail := flag.Bool("cocktail", false, "cocktail cabinet, the screen flips for player 2")
+	swapControls := flag.Bool("swap-controls", false, "cocktail: player 1 controls drive player 2 while flipped")
+	rotate := flag.Int("rotate", 0, "rotate the screen by 0, 90, 180 or 270 degrees")
+	captureDir := flag.String("capture-dir", ".", "directory for screenshots and recordings")
+	phosphor := flag.Duration("phosphor", 0, "phosphor half-life of the monitor, e.g. 8ms, 0 for none")
+	filters := flag.String("filters", "", "post-processing chain, e.g. scale=3,scanlines,bloom ("+
+		strings.Join(filter.Names(), ", ")+")")
+	autofire := flag.Int("autofire", 0, "repeat the fire buttons every n frames while held, 0 for off")
+	keymap := flag.String("keymap", "", "key bindings file")
+	dumpVideo := flag.String("dump-video", "", "dump every frame to a Y4M file")
+	dumpAudio := flag.String("dump-audio", "", "dump the sound to a WAV file")
+	recordInput := flag.String("record-input", "", "record the inputs to a file")
+	playInput := flag.String("play-input", "", "play back the inputs recorded in a file")
+	patches := flag.String("patch", "", "IPS or BPS patches applied to the ROM, comma separated, by default "+
+		"the ones next to the ROM, none for none")
+	cheatDir := flag.String("cheats", "", "directory of the cheat files, by default in the user config directory")
+	hiScoreDir := flag.String("hiscores", "", "directory of the saved hi-scores, by default in the user data directory")
+	initials := flag.Bool("initials", false, "keep a table of the 10 best scores, with initials entered after the game")
+	console := flag.Bool("console", false, "read cheat and RAM search commands on the standard input")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: invaders8080 [flags] <rom directory | rom.zip | rom files...>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	romPath := args[0]
+	if *list {
+		for _, profile := range invaders.Profiles() {
+			fmt.Printf("%-10s %s\n", profile.Name, profile.Description)
+		}
+		return
+	}
 
-	game := invaders.New()
-	game.Run(romPath)
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	profile, err := invaders.LookupProfile(*gameName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	scaleMode, err := frontend.ParseScaleMode(*scale)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	rotation, err := frontend.ParseRotation(*rotate)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	config := invaders.Config{Profile: profile, CPUClock: *cpuClock, RefreshRate: *refresh, TotalLines: *lines}
+	machine, err := invaders.NewMachine(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	machine.SetCocktail(invaders.Cocktail{Enabled: *cocktail, SwapControls: *swapControls})
+
+	game := frontend.New(machine)
+	game.SetDisplay(frontend.Display{Scale: scaleMode, Linear: *linear, Fullscreen: *fullscreen, VSync: *vsync})
+	game.SetRotation(rotation)
+	game.SetCaptureDir(*captureDir)
+
+	switch *overlay {
+	case "default":
+	case "none":
+		machine.SetOverlays(nil)
+	default:
+		overlays, err := invaders.LoadOverlays(*overlay)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		machine.SetOverlays(overlays)
+	}
+
+	if *layout != "" {
+		l, err := frontend.LoadLayout(*layout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		game.SetLayout(l)
+	}
+
+	if *keymap != "" {
+		k, err := frontend.LoadKeymap(*keymap)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		game.SetKeymap(k)
+	}
+
+	if err := game.SetSpeed(*speed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *autofire < 0 {
+		fmt.Fprintln(os.Stderr, "autofire rate must be positive")
+		os.Exit(1)
+	}
+	game.SetAutofire(*autofire)
+
+	machine.SetPhosphorHalfLife(*phosphor)
+
+	chain, err := filter.Parse(*filters)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	game.SetFilters(chain)
+
+	for _, path := range args {
+		fmt.Printf("Loading %s\n", path)
+	}
+	if err := machine.LoadROMFiles(args...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var patchFiles []string
+	switch *patches {
+	case "":
+		patchFiles = patch.Find(args...)
+	case "none":
+	default:
+		patchFiles = strings.Split(*patches, ",")
+	}
+	for _, path := range patchFiles {
+		fmt.Printf("Patching %s\n", path)
+		if err := machine.ApplyPatchFile(path); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	cheats, cheatPath, err := loadCheats(*cheatDir, machine)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	game.SetCheats(cheats, cheatPath)
+
+	if machine.ScoresSupported() {
+		table, path := loadHiScores(*hiScoreDir, machine)
+		game.SetHiScores(table, path, *initials)
+	}
+	if *console {
+		game.StartConsole(os.Stdin)
+	}
+
+	var files []*os.File
+	create := func(path string) *os.File {
+		file, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		files = append(files, file)
+		return file
+	}
+
+	if *playInput != "" {
+		file, err := os.Open(*playInput)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		files = append(files, file)
+		if err := game.PlayInput(file); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *playInput, err)
+			os.Exit(1)
+		}
+	}
+	if *recordInput != "" {
+		if err := game.RecordInput(create(*recordInput)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if *dumpVideo != "" {
+		game.DumpVideo(create(*dumpVideo))
+	}
+	if *dumpAudio != "" {
+		if err := game.DumpAudio(create(*dumpAudio)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	game.Run()
+
+	for _, file := range files {
+		if err := file.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// cheats of the ROM loaded, from its file in dir or the built-in ones, and
+// the file they are saved to
+func loadCheats(dir string, machine *invaders.Machine) ([]cheat.Cheat, string, error) {
+	if dir == "" {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return cheat.Builtins(machine.Profile().Name), "", nil
+		}
+		dir = filepath.Join(config, "invaders8080", "cheats")
+	}
+
+	path := cheat.Path(dir, machine.ROMHash())
+	cheats, err := cheat.Load(path)
+	if os.IsNotExist(err) {
+		return cheat.Builtins(machine.Profile().Name), path, nil
+	}
+	return cheats, path, err
+}
+
+// hi-score table of the ROM loaded from its file in dir, and the file. A
+// table that cannot be read is started empty and not saved, leaving the file
+// as it is
+func loadHiScores(dir string, machine *invaders.Machine) (*hiscore.Table, string) {
+	if dir == "" {
+		var err error
+		if dir, err = hiscore.DefaultDir(); err != nil {
+			fmt.Fprintf(os.Stderr, "Hi-scores not saved: %s\n", err)
+			return &hiscore.Table{}, ""
+		}
+	}
+
+	path := hiscore.Path(dir, machine.ROMHash())
+	table, err := hiscore.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Hi-scores not saved: %s\n", err)
+		return &hiscore.Table{}, ""
+	}
+	return table, path
 }
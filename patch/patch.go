@@ -0,0 +1,269 @@
+// Package patch applies IPS and BPS patches to ROM images, as ROM hacks are
+// distributed
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Magic numbers of the formats
+const (
+	ipsMagic = "PATCH"
+	ipsEOF   = "EOF"
+	bpsMagic = "BPS1"
+)
+
+// MaxSize of a patched image, the 16 MB an IPS patch can address
+const MaxSize = 1 << 24
+
+// Extensions of the patch files, in the order they are applied
+var Extensions = []string{".ips", ".bps"}
+
+// Apply the patch to the image, its format is told by its header. The image
+// is left untouched
+func Apply(image, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte(ipsMagic)):
+		return ApplyIPS(image, patch)
+	case bytes.HasPrefix(patch, []byte(bpsMagic)):
+		return ApplyBPS(image, patch)
+	}
+	return nil, fmt.Errorf("unknown patch format")
+}
+
+// ApplyIPS applies an IPS patch, records of a 24-bit offset and a 16-bit
+// size followed by the data, or by a run length and a byte when the size is
+// 0. The image grows to fit records past its end
+func ApplyIPS(image, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(ipsMagic)) {
+		return nil, fmt.Errorf("not an IPS patch")
+	}
+
+	out := append([]byte(nil), image...)
+	write := func(offset int, data []byte) {
+		if end := offset + len(data); end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	p := patch[len(ipsMagic):]
+	for {
+		if len(p) >= len(ipsEOF) && string(p[:len(ipsEOF)]) == ipsEOF && (len(p) == 3 || len(p) == 6) {
+			// optionally followed by the size to truncate to
+			if len(p) == 6 {
+				size := int(p[3])<<16 | int(p[4])<<8 | int(p[5])
+				if size < len(out) {
+					out = out[:size]
+				}
+			}
+			return out, nil
+		}
+
+		if len(p) < 5 {
+			return nil, fmt.Errorf("truncated IPS patch")
+		}
+		offset := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		size := int(binary.BigEndian.Uint16(p[3:5]))
+		p = p[5:]
+
+		if size > 0 {
+			if len(p) < size {
+				return nil, fmt.Errorf("truncated IPS record at %06x", offset)
+			}
+			write(offset, p[:size])
+			p = p[size:]
+			continue
+		}
+
+		// run length encoded
+		if len(p) < 3 {
+			return nil, fmt.Errorf("truncated IPS record at %06x", offset)
+		}
+		run := int(binary.BigEndian.Uint16(p[:2]))
+		write(offset, bytes.Repeat(p[2:3], run))
+		p = p[3:]
+	}
+}
+
+// ApplyBPS applies a BPS patch, checking the CRC-32 of the image, of the
+// result and of the patch itself
+func ApplyBPS(image, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(bpsMagic)) {
+		return nil, fmt.Errorf("not a BPS patch")
+	}
+	if len(patch) < len(bpsMagic)+12 {
+		return nil, fmt.Errorf("truncated BPS patch")
+	}
+
+	footer := patch[len(patch)-12:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:4])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:8])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:12])
+
+	if sum := crc32.ChecksumIEEE(patch[:len(patch)-4]); sum != patchCRC {
+		return nil, fmt.Errorf("corrupt BPS patch, crc32 is %08x, expected %08x", sum, patchCRC)
+	}
+	if sum := crc32.ChecksumIEEE(image); sum != sourceCRC {
+		return nil, fmt.Errorf("BPS patch is for another image, crc32 is %08x, expected %08x", sum, sourceCRC)
+	}
+
+	r := &bpsReader{data: patch[:len(patch)-12], pos: len(bpsMagic)}
+	sourceSize, targetSize, metadataSize := r.number(), r.number(), r.number()
+	r.skip(metadataSize)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if sourceSize != uint64(len(image)) {
+		return nil, fmt.Errorf("BPS patch is for an image of %d bytes, not %d", sourceSize, len(image))
+	}
+	if targetSize > MaxSize {
+		return nil, fmt.Errorf("BPS patch makes an image of %d bytes, at most %d", targetSize, MaxSize)
+	}
+
+	target := make([]byte, targetSize)
+	var out, sourceOffset, targetOffset int
+	for r.pos < len(r.data) && r.err == nil {
+		action := r.number()
+		command := action & 3
+		// checked before the conversion, a huge length would overflow
+		if action>>2 >= uint64(len(target)-out) {
+			return nil, fmt.Errorf("BPS patch writes past the end of the image")
+		}
+		length := int(action>>2) + 1
+
+		switch command {
+		case 0: // source read
+			if out > len(image)-length {
+				return nil, fmt.Errorf("BPS patch reads past the end of the image")
+			}
+			copy(target[out:], image[out:out+length])
+
+		case 1: // target read
+			copy(target[out:], r.bytes(length))
+
+		case 2: // source copy
+			sourceOffset += r.offset()
+			if sourceOffset < 0 || sourceOffset > len(image)-length {
+				return nil, fmt.Errorf("BPS patch copies from outside the image")
+			}
+			copy(target[out:], image[sourceOffset:sourceOffset+length])
+			sourceOffset += length
+
+		case 3: // target copy, byte by byte as the ranges may overlap
+			targetOffset += r.offset()
+			if targetOffset < 0 || targetOffset >= out {
+				return nil, fmt.Errorf("BPS patch copies from outside the result")
+			}
+			for i := 0; i < length; i++ {
+				target[out+i] = target[targetOffset+i]
+			}
+			targetOffset += length
+		}
+		out += length
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if out != len(target) {
+		return nil, fmt.Errorf("BPS patch ends after %d bytes of %d", out, len(target))
+	}
+
+	if sum := crc32.ChecksumIEEE(target); sum != targetCRC {
+		return nil, fmt.Errorf("BPS patch gives a bad image, crc32 is %08x, expected %08x", sum, targetCRC)
+	}
+	return target, nil
+}
+
+// reads the variable length numbers and the data of a BPS patch
+type bpsReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *bpsReader) number() uint64 {
+	var n, shift uint64 = 0, 1
+	for {
+		if r.pos >= len(r.data) {
+			r.fail()
+			return 0
+		}
+		x := r.data[r.pos]
+		r.pos++
+
+		n += uint64(x&0x7f) * shift
+		if x&0x80 != 0 {
+			return n
+		}
+		shift <<= 7
+		n += shift
+	}
+}
+
+// relative offset, the low bit is the sign
+func (r *bpsReader) offset() int {
+	n := r.number()
+	if n>>1 > MaxSize {
+		// out of any image, and kept from overflowing the offsets
+		return MaxSize + 1
+	}
+	if n&1 != 0 {
+		return -int(n >> 1)
+	}
+	return int(n >> 1)
+}
+
+func (r *bpsReader) bytes(n int) []byte {
+	if r.pos+n > len(r.data) {
+		r.fail()
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *bpsReader) skip(n uint64) {
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail()
+		return
+	}
+	r.pos += int(n)
+}
+
+func (r *bpsReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("truncated BPS patch")
+	}
+	r.pos = len(r.data)
+}
+
+// Find the patches next to the ROM files, named after them with an .ips or
+// .bps extension instead of their own: invaders.zip finds invaders.ips
+func Find(paths ...string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		base := strings.TrimSuffix(path, filepath.Ext(path))
+		for _, ext := range Extensions {
+			candidate := base + ext
+			if seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				found = append(found, candidate)
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,266 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+func TestApplyIPS(t *testing.T) {
+	image := []byte{0, 1, 2, 3, 4, 5, 6, 7}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  []byte
+		err   string
+	}{
+		{"empty", "PATCHEOF", image, ""},
+		{"record", "PATCH\x00\x00\x02\x00\x02\xaa\xbbEOF", []byte{0, 1, 0xaa, 0xbb, 4, 5, 6, 7}, ""},
+		{"rle", "PATCH\x00\x00\x01\x00\x00\x00\x03\xccEOF", []byte{0, 0xcc, 0xcc, 0xcc, 4, 5, 6, 7}, ""},
+		{"grows", "PATCH\x00\x00\x07\x00\x02\xaa\xbbEOF", []byte{0, 1, 2, 3, 4, 5, 6, 0xaa, 0xbb}, ""},
+		{"truncates", "PATCHEOF\x00\x00\x04", []byte{0, 1, 2, 3}, ""},
+		{"truncation past the end", "PATCHEOF\x00\x01\x00", image, ""},
+		{"no eof", "PATCH", nil, "truncated IPS patch"},
+		{"truncated header", "PATCH\x00\x00\x01\x00", nil, "truncated IPS patch"},
+		{"truncated data", "PATCH\x00\x00\x01\x00\x05\xaaEOF", nil, "truncated IPS record at 000001"},
+		{"truncated rle", "PATCH\x00\x00\x01\x00\x00\x00", nil, "truncated IPS record at 000001"},
+		{"not ips", "BPS1", nil, "not an IPS patch"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ApplyIPS(image, []byte(test.patch))
+			checkResult(t, got, err, test.want, test.err)
+		})
+	}
+
+	if !bytes.Equal(image, []byte{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("image modified to % x", image)
+	}
+}
+
+func TestApplyBPS(t *testing.T) {
+	image := []byte("abcdefgh")
+
+	tests := []struct {
+		name    string
+		target  string
+		actions []interface{} // numbers, and strings read by the target reads
+		want    string
+		err     string
+	}{
+		{
+			name:    "source read",
+			target:  "abcdefgh",
+			actions: []interface{}{action(0, 8)},
+			want:    "abcdefgh",
+		},
+		{
+			name:    "target read",
+			target:  "abXYefgh",
+			actions: []interface{}{action(0, 2), action(1, 2), "XY", action(0, 4)},
+			want:    "abXYefgh",
+		},
+		{
+			name:    "source copy",
+			target:  "ghabcdef",
+			actions: []interface{}{action(2, 2), offset(6), action(2, 6), offset(-8)},
+			want:    "ghabcdef",
+		},
+		{
+			name:    "overlapping target copy",
+			target:  "aaaaaaaa",
+			actions: []interface{}{action(1, 1), "a", action(3, 7), offset(0)},
+			want:    "aaaaaaaa",
+		},
+		{
+			name:    "write past the end",
+			target:  "abcdefgh",
+			actions: []interface{}{action(0, 9)},
+			err:     "BPS patch writes past the end of the image",
+		},
+		{
+			name:    "huge length",
+			target:  "abcdefgh",
+			actions: []interface{}{uint64(1<<63 | 1)},
+			err:     "BPS patch writes past the end of the image",
+		},
+		{
+			name:    "source read past the end",
+			target:  "abcdefghij",
+			actions: []interface{}{action(0, 10)},
+			err:     "BPS patch reads past the end of the image",
+		},
+		{
+			name:    "source copy before the start",
+			target:  "abcdefgh",
+			actions: []interface{}{action(2, 1), offset(-1)},
+			err:     "BPS patch copies from outside the image",
+		},
+		{
+			name:    "huge source offset",
+			target:  "abcdefgh",
+			actions: []interface{}{action(2, 1), uint64(1 << 63)},
+			err:     "BPS patch copies from outside the image",
+		},
+		{
+			name:    "target copy ahead",
+			target:  "abcdefgh",
+			actions: []interface{}{action(3, 1), offset(0)},
+			err:     "BPS patch copies from outside the result",
+		},
+		{
+			name:    "truncated target read",
+			target:  "abcdefgh",
+			actions: []interface{}{action(1, 8), "abc"},
+			err:     "truncated BPS patch",
+		},
+		{
+			name:    "short",
+			target:  "abcdefgh",
+			actions: []interface{}{action(0, 4)},
+			err:     "BPS patch ends after 4 bytes of 8",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := bpsPatch(image, []byte(test.target), uint64(len(test.target)), test.actions)
+			got, err := ApplyBPS(image, p)
+			checkResult(t, got, err, []byte(test.want), test.err)
+		})
+	}
+}
+
+func TestApplyBPSChecksums(t *testing.T) {
+	image := []byte("abcdefgh")
+	valid := func() []byte {
+		return bpsPatch(image, image, uint64(len(image)), []interface{}{action(0, 8)})
+	}
+
+	tests := []struct {
+		name  string
+		patch func() []byte
+		image []byte
+		err   string
+	}{
+		{
+			name:  "source",
+			patch: valid,
+			image: []byte("abcdefgX"),
+			err:   "BPS patch is for another image",
+		},
+		{
+			name: "target",
+			patch: func() []byte {
+				return bpsPatch(image, []byte("abcdefgX"), uint64(len(image)), []interface{}{action(0, 8)})
+			},
+			image: image,
+			err:   "BPS patch gives a bad image",
+		},
+		{
+			name: "patch",
+			patch: func() []byte {
+				p := valid()
+				p[len(p)-1] ^= 0xff
+				return p
+			},
+			image: image,
+			err:   "corrupt BPS patch",
+		},
+		{
+			name: "huge target",
+			patch: func() []byte {
+				return bpsPatch(image, image, MaxSize+1, []interface{}{action(0, 8)})
+			},
+			image: image,
+			err:   "BPS patch makes an image of 16777217 bytes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ApplyBPS(test.image, test.patch())
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	if _, err := Apply(nil, []byte("UPS1")); err == nil {
+		t.Errorf("unknown format applied")
+	}
+	got, err := Apply([]byte{1}, []byte("PATCH\x00\x00\x00\x00\x01\x02EOF"))
+	checkResult(t, got, err, []byte{2}, "")
+}
+
+func checkResult(t *testing.T, got []byte, err error, want []byte, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("got error %v, want %q", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+// BPS patch of the actions from image to a target of the size, checked
+// against target. Numbers are encoded, strings copied as they are
+func bpsPatch(image, target []byte, size uint64, actions []interface{}) []byte {
+	p := []byte(bpsMagic)
+	p = appendNumber(p, uint64(len(image)))
+	p = appendNumber(p, size)
+	p = appendNumber(p, 0)
+	for _, a := range actions {
+		switch a := a.(type) {
+		case uint64:
+			p = appendNumber(p, a)
+		case string:
+			p = append(p, a...)
+		}
+	}
+
+	p = appendCRC(p, crc32.ChecksumIEEE(image))
+	p = appendCRC(p, crc32.ChecksumIEEE(target))
+	return appendCRC(p, crc32.ChecksumIEEE(p))
+}
+
+func action(command, length uint64) uint64 {
+	return (length-1)<<2 | command
+}
+
+func offset(n int) uint64 {
+	if n < 0 {
+		return uint64(-n)<<1 | 1
+	}
+	return uint64(n) << 1
+}
+
+func appendNumber(p []byte, n uint64) []byte {
+	for {
+		x := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(p, 0x80|x)
+		}
+		p = append(p, x)
+		n--
+	}
+}
+
+func appendCRC(p []byte, sum uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], sum)
+	return append(p, b[:]...)
+}